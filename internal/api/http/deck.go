@@ -0,0 +1,138 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"who-is-spy-be/internal/service"
+	"who-is-spy-be/internal/service/game"
+	"who-is-spy-be/internal/service/wordbank"
+	"who-is-spy-be/internal/state"
+
+	"github.com/kataras/iris/v12"
+)
+
+// 各格式导出时使用的 Content-Type
+var deckContentTypes = map[string]string{
+	wordbank.FORMAT_JSON: "application/json; charset=utf-8",
+	wordbank.FORMAT_YAML: "application/yaml; charset=utf-8",
+	wordbank.FORMAT_CSV:  "text/csv; charset=utf-8",
+}
+
+// deckFormat 优先使用 format 参数，其次根据 Content-Type 推断，默认为 JSON
+func deckFormat(ctx iris.Context) (string, error) {
+	format := strings.ToLower(ctx.URLParamTrim("format"))
+
+	if format == "" {
+		contentType := strings.ToLower(ctx.GetContentTypeRequested())
+		switch {
+		case strings.Contains(contentType, "csv"):
+			format = wordbank.FORMAT_CSV
+		case strings.Contains(contentType, "yaml"):
+			format = wordbank.FORMAT_YAML
+		default:
+			format = wordbank.FORMAT_JSON
+		}
+	}
+
+	if _, ok := deckContentTypes[format]; !ok {
+		return "", fmt.Errorf("不支持的词库格式：%s", format)
+	}
+
+	return format, nil
+}
+
+func UploadDeck(appState *state.AppState) iris.Handler {
+	return func(ctx iris.Context) {
+		format, err := deckFormat(ctx)
+		if err != nil {
+			ctx.StatusCode(iris.StatusBadRequest)
+			ctx.JSON(iris.Map{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		data, err := io.ReadAll(io.LimitReader(ctx.Request().Body, service.MAX_DECK_UPLOAD_BYTES+1))
+		if err != nil {
+			ctx.StatusCode(iris.StatusBadRequest)
+			ctx.JSON(iris.Map{
+				"error": "读取词库文件失败",
+			})
+			return
+		}
+
+		if len(data) > service.MAX_DECK_UPLOAD_BYTES {
+			ctx.StatusCode(iris.StatusRequestEntityTooLarge)
+			ctx.JSON(iris.Map{
+				"error": "词库文件过大",
+			})
+			return
+		}
+
+		req := game.UploadDeckRequest{
+			Name:         ctx.URLParamTrim("name"),
+			RoomID:       ctx.URLParamTrim("room_id"),
+			CreatorToken: ctx.GetHeader("X-Creator-Token"),
+			UploadToken:  ctx.GetHeader("X-Upload-Token"),
+			Format:       format,
+		}
+
+		resp, err := appState.RoomSvc.UploadDeck(req, data)
+		if err != nil {
+			ctx.StatusCode(iris.StatusBadRequest)
+			ctx.JSON(iris.Map{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		ctx.JSON(resp)
+	}
+}
+
+func ListDecks(appState *state.AppState) iris.Handler {
+	return func(ctx iris.Context) {
+		ctx.JSON(appState.RoomSvc.ListDecks(ctx.URLParamTrim("room_id")))
+	}
+}
+
+func DownloadDeck(appState *state.AppState) iris.Handler {
+	return func(ctx iris.Context) {
+		format, err := deckFormat(ctx)
+		if err != nil {
+			ctx.StatusCode(iris.StatusBadRequest)
+			ctx.JSON(iris.Map{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		deck, data, err := appState.RoomSvc.ExportDeck(
+			ctx.Params().Get("id"),
+			ctx.URLParamTrim("room_id"),
+			ctx.GetHeader("X-Creator-Token"),
+			format,
+		)
+		if err != nil {
+			if errors.Is(err, service.ErrDeckForbidden) {
+				ctx.StatusCode(iris.StatusForbidden)
+			} else {
+				ctx.StatusCode(iris.StatusNotFound)
+			}
+			ctx.JSON(iris.Map{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		filename := url.PathEscape(deck.Name + "." + format)
+
+		ctx.ContentType(deckContentTypes[format])
+		ctx.Header("Content-Disposition", "attachment; filename*=UTF-8''"+filename)
+		ctx.Write(data)
+	}
+}
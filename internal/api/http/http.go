@@ -24,8 +24,13 @@ func RunServer(appState *state.AppState) {
 
 	api := app.Party("/api/v1")
 
+	api.Get("/rooms", ListRooms(appState))
 	api.Post("/rooms/create", CreateRoom(appState))
 
+	api.Get("/decks", ListDecks(appState))
+	api.Post("/decks", UploadDeck(appState))
+	api.Get("/decks/{id}", DownloadDeck(appState))
+
 	api.Get("/ws/join", websocket.JoinGame(appState))
 
 	addr := fmt.Sprintf(
@@ -31,3 +31,25 @@ func CreateRoom(appState *state.AppState) iris.Handler {
 		ctx.JSON(resp)
 	}
 }
+
+func ListRooms(appState *state.AppState) iris.Handler {
+	return func(ctx iris.Context) {
+		req := game.ListRoomsRequest{
+			Page:     ctx.URLParamIntDefault("page", 1),
+			PageSize: ctx.URLParamIntDefault("page_size", 0),
+			Name:     ctx.URLParamTrim("name"),
+			Stage:    ctx.URLParamTrim("stage"),
+		}
+
+		resp, err := appState.RoomSvc.ListRooms(req)
+		if err != nil {
+			ctx.StatusCode(iris.StatusBadRequest)
+			ctx.JSON(iris.Map{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		ctx.JSON(resp)
+	}
+}
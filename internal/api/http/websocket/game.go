@@ -2,6 +2,7 @@ package websocket
 
 import (
 	"encoding/json"
+	"sync"
 	"time"
 
 	"who-is-spy-be/internal/service/game"
@@ -67,6 +68,9 @@ func JoinGame(appState *state.AppState) iris.Handler {
 			return
 		}
 
+		// 客户端 IP 由连接层提供，供房间封禁使用
+		req.RemoteIP = ctx.RemoteAddr()
+
 		// 先调用加入房间的接口，获取游戏状态机的请求通道
 		reqCh, err := appState.RoomSvc.JoinRoom(req, respCh)
 		if err != nil {
@@ -76,6 +80,9 @@ func JoinGame(appState *state.AppState) iris.Handler {
 				zap.Error(err),
 			)
 
+			// 告知客户端失败原因（例如重连凭证无效）后再关闭连接
+			conn.WriteJSON(game.WrapErrResponse(err.Error()))
+
 			return
 		}
 
@@ -122,6 +129,17 @@ func JoinGame(appState *state.AppState) iris.Handler {
 
 		clientIP := ctx.RemoteAddr()
 
+		// 连接同时只允许一个写入者：写协程转发响应，读协程直接回写请求错误。
+		// 读协程不能写入 respCh，状态机踢出或顶替玩家时会在连接关闭前关闭该通道
+		var writeMu sync.Mutex
+
+		writeJSON := func(v any) error {
+			writeMu.Lock()
+			defer writeMu.Unlock()
+
+			return conn.WriteJSON(v)
+		}
+
 		// 写入协程
 		go func() {
 			ticker := time.NewTicker(HEARTBEAT_INTERVAL)
@@ -137,7 +155,14 @@ func JoinGame(appState *state.AppState) iris.Handler {
 					return
 
 				case <-ticker.C:
-					if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+					writeMu.Lock()
+					err := conn.WriteMessage(websocket.PingMessage, nil)
+					if err == nil {
+						conn.SetWriteDeadline(time.Now().Add(HEARTBEAT_TIMEOUT))
+					}
+					writeMu.Unlock()
+
+					if err != nil {
 						zap.L().Error(
 							"发送心跳失败",
 							zap.String("client_ip", clientIP),
@@ -146,24 +171,24 @@ func JoinGame(appState *state.AppState) iris.Handler {
 						return
 					}
 
-					conn.SetWriteDeadline(time.Now().Add(HEARTBEAT_TIMEOUT))
-
 					zap.L().Debug(
 						"发送心跳",
 						zap.String("client_ip", clientIP),
 					)
 
 				case resp, ok := <-respCh:
-					// 检测到channel已关闭（玩家退出时状态机关闭了通道）
+					// 检测到channel已关闭（玩家退出、被踢出或被顶替时状态机关闭了通道）
+					// 同时关闭连接，使读协程退出，不再向状态机转发该连接的请求
 					if !ok {
 						zap.L().Info(
 							"响应通道已关闭，退出写协程",
 							zap.String("client_ip", clientIP),
 						)
+						conn.Close()
 						return
 					}
 
-					if err := conn.WriteJSON(resp); err != nil {
+					if err := writeJSON(resp); err != nil {
 						zap.L().Error(
 							"发送消息失败",
 							zap.String("client_ip", clientIP),
@@ -218,11 +243,14 @@ func JoinGame(appState *state.AppState) iris.Handler {
 				)
 
 				// 解析石板，返回错误响应
-				respCh <- game.WrapErrResponse("无效的请求格式")
+				writeJSON(game.WrapErrResponse("无效的请求格式"))
 
 				continue
 			}
 
+			// 以连接绑定的玩家身份为准，状态机会拒绝声明了其他玩家 ID 的请求
+			wrapper.SenderID = playerID
+
 			// 将解析后的请求发送到游戏状态机
 			select {
 			case reqCh <- wrapper:
@@ -238,22 +266,23 @@ func JoinGame(appState *state.AppState) iris.Handler {
 				)
 
 				// 返回错误响应
-				respCh <- game.WrapErrResponse("房间繁忙，请稍后再试")
+				writeJSON(game.WrapErrResponse("房间繁忙，请稍后再试"))
 			}
 		}
 
 		// 读循环退出，表示客户端断开连接
-		// 发送 ExitGame 请求通知游戏状态机清理玩家
+		// 发送断线请求通知游戏状态机，由状态机决定保留席位还是清理玩家
 		zap.L().Info(
 			"客户端连接断开，发送退出请求",
 			zap.String("client_ip", clientIP),
 			zap.String("player_id", playerID),
 		)
 
-		// server-generated exit should not expect a response; do not attach respCh
+		// server-generated exit should not expect a response; respCh only identifies this connection
 		exitReq := game.ExitGameRequest{
-			PlayerID: playerID,
-			RespCh:   nil,
+			PlayerID:     playerID,
+			Disconnected: true,
+			RespCh:       respCh,
 		}
 
 		exitWrapper := game.RequestWrapper{
@@ -10,6 +10,51 @@ type AppConfig struct {
 	Host     string `mapstructure:"host"`
 	Port     int    `mapstructure:"port"`
 	LogLevel string `mapstructure:"log_level"`
+
+	Room     RoomConfig     `mapstructure:"room"`
+	Reaper   ReaperConfig   `mapstructure:"reaper"`
+	WordBank WordBankConfig `mapstructure:"word_bank"`
+}
+
+// RoomConfig 房间相关配置，时间单位均为秒
+type RoomConfig struct {
+	// 房主未携带凭证加入时保留管理员席位的宽限期，<= 0 表示一直保留
+	CreatorGraceSeconds int `mapstructure:"creator_grace_seconds"`
+	// 断线宽限期，期间保留断线玩家的身份和席位，<= 0 表示断线即离开
+	DisconnectGraceSeconds int `mapstructure:"disconnect_grace_seconds"`
+	// 签发重连凭证的 HMAC 密钥，为空时每次启动随机生成
+	ReconnectSecret string `mapstructure:"reconnect_secret"`
+	// 各阶段默认时长，房间创建时未指定的字段使用这里的值
+	Timing TimingConfig `mapstructure:"timing"`
+}
+
+// TimingConfig 服务器默认的阶段时长，秒为单位，全局游戏时长以分钟为单位
+type TimingConfig struct {
+	PrepareSeconds    int `mapstructure:"prepare_seconds"`
+	FirstSpeakSeconds int `mapstructure:"first_speak_seconds"`
+	SpeakSeconds      int `mapstructure:"speak_seconds"`
+	VoteSeconds       int `mapstructure:"vote_seconds"`
+	JudgeSeconds      int `mapstructure:"judge_seconds"`
+	GuessSeconds      int `mapstructure:"guess_seconds"`
+	GameLimitMinutes  int `mapstructure:"game_limit_minutes"`
+}
+
+// ReaperConfig 房间回收器配置，时间单位均为秒
+type ReaperConfig struct {
+	// 回收器巡检间隔
+	IntervalSeconds int `mapstructure:"interval_seconds"`
+	// 创建后无人加入的房间最长保留时间
+	EmptyRoomTTLSeconds int `mapstructure:"empty_room_ttl_seconds"`
+	// 所有玩家均已断线的房间最长保留时间
+	IdleGraceSeconds int `mapstructure:"idle_grace_seconds"`
+}
+
+// WordBankConfig 内置词库配置
+type WordBankConfig struct {
+	// 词库文件路径，支持 JSON 和 YAML；文件不存在时词库为空，只能手动设置词语
+	Path string `mapstructure:"path"`
+	// 上传全服词库的凭证，为空时不允许上传全服词库
+	UploadToken string `mapstructure:"upload_token"`
 }
 
 var cfg *AppConfig
@@ -29,6 +74,8 @@ func InitConfig() *AppConfig {
 	v.SetConfigType("json")
 	v.AddConfigPath(".")
 
+	setDefaults(v)
+
 	if err := v.ReadInConfig(); err != nil {
 		panic(fmt.Errorf("加载配置失败: %w", err))
 	}
@@ -41,3 +88,23 @@ func InitConfig() *AppConfig {
 
 	return &config
 }
+
+func setDefaults(v *viper.Viper) {
+	v.SetDefault("room.creator_grace_seconds", 120)
+	v.SetDefault("room.disconnect_grace_seconds", 60)
+	v.SetDefault("room.reconnect_secret", "")
+	v.SetDefault("room.timing.prepare_seconds", 30)
+	v.SetDefault("room.timing.first_speak_seconds", 40)
+	v.SetDefault("room.timing.speak_seconds", 20)
+	v.SetDefault("room.timing.vote_seconds", 30)
+	v.SetDefault("room.timing.judge_seconds", 10)
+	v.SetDefault("room.timing.guess_seconds", 30)
+	v.SetDefault("room.timing.game_limit_minutes", 30)
+
+	v.SetDefault("reaper.interval_seconds", 30)
+	v.SetDefault("reaper.empty_room_ttl_seconds", 600)
+	v.SetDefault("reaper.idle_grace_seconds", 300)
+
+	v.SetDefault("word_bank.path", "word_bank.yaml")
+	v.SetDefault("word_bank.upload_token", "")
+}
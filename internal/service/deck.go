@@ -0,0 +1,143 @@
+package service
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+
+	"who-is-spy-be/internal/service/game"
+	"who-is-spy-be/internal/service/wordbank"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// 上传词库文件的大小上限（字节）
+	MAX_DECK_UPLOAD_BYTES = 1 << 20
+	// 词库名称的长度上限（字符）
+	MAX_DECK_NAME_LENGTH = 50
+)
+
+// ErrDeckForbidden 下载房间词库时未提供正确的房主凭证
+var ErrDeckForbidden = errors.New("只有房主可以下载房间词库")
+
+// UploadDeck 解析并校验上传的词库，保存为全服词库或房间词库
+func (rs *RoomService) UploadDeck(
+	args game.UploadDeckRequest,
+	data []byte,
+) (
+	*wordbank.DeckInfo,
+	error,
+) {
+	name := strings.TrimSpace(args.Name)
+	if name == "" {
+		return nil, errors.New("词库名称不能为空")
+	}
+
+	if len([]rune(name)) > MAX_DECK_NAME_LENGTH {
+		return nil, fmt.Errorf("词库名称不能超过 %d 个字符", MAX_DECK_NAME_LENGTH)
+	}
+
+	if args.RoomID != "" {
+		ok, err := rs.checkCreatorToken(args.RoomID, args.CreatorToken)
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			return nil, errors.New("只有房主可以上传房间词库")
+		}
+	} else {
+		// 全服词库不会过期，未配置上传凭证时不允许上传，避免被匿名请求占满
+		uploadToken := rs.cfg.WordBank.UploadToken
+		if uploadToken == "" {
+			return nil, errors.New("服务器未开放上传全服词库")
+		}
+
+		if subtle.ConstantTimeCompare([]byte(uploadToken), []byte(args.UploadToken)) != 1 {
+			return nil, errors.New("上传全服词库的凭证不正确")
+		}
+	}
+
+	pairs, err := wordbank.Decode(data, args.Format)
+	if err != nil {
+		return nil, fmt.Errorf("解析词库失败：%w", err)
+	}
+
+	bank, err := wordbank.New(pairs)
+	if err != nil {
+		return nil, err
+	}
+
+	deck, err := rs.decks.Add(name, args.RoomID, bank)
+	if err != nil {
+		return nil, err
+	}
+
+	zap.L().Info(
+		"上传词库",
+		zap.String("deck_id", deck.ID),
+		zap.String("room_id", deck.RoomID),
+		zap.Int("pairs", bank.Len()),
+	)
+
+	info := deck.Info()
+
+	return &info, nil
+}
+
+// ListDecks 返回全服词库，以及指定房间的词库
+func (rs *RoomService) ListDecks(roomID string) *game.ListDecksResponse {
+	return &game.ListDecksResponse{
+		Decks: rs.decks.List(roomID),
+	}
+}
+
+// ExportDeck 按指定格式导出词库，房间词库需要提供所属房间 ID 和房主凭证，避免玩家提前看到词语
+func (rs *RoomService) ExportDeck(
+	deckID string,
+	roomID string,
+	creatorToken string,
+	format string,
+) (
+	*wordbank.Deck,
+	[]byte,
+	error,
+) {
+	if roomID != "" {
+		ok, err := rs.checkCreatorToken(roomID, creatorToken)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if !ok {
+			return nil, nil, ErrDeckForbidden
+		}
+	}
+
+	deck, err := rs.decks.Get(deckID, roomID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err := wordbank.Encode(deck.Bank.Pairs(), format)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return deck, data, nil
+}
+
+// checkCreatorToken 校验房间的房主凭证，房间不存在时返回错误
+func (rs *RoomService) checkCreatorToken(roomID string, creatorToken string) (bool, error) {
+	rs.mu.Lock()
+	gameHnd, ok := rs.gameHndMap[roomID]
+	rs.mu.Unlock()
+
+	if !ok {
+		return false, errors.New("房间不存在")
+	}
+
+	return subtle.ConstantTimeCompare([]byte(gameHnd.creatorToken), []byte(creatorToken)) == 1, nil
+}
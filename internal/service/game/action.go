@@ -1,30 +1,95 @@
 package game
 
+import (
+	"time"
+
+	"who-is-spy-be/internal/service/wordbank"
+)
+
 type CreateRoomRequest struct {
 	RoomName    string `json:"room_name"`
 	CreatorName string `json:"creator_name"`
+	// 可选的房间设置，不提供时使用标准 8 人局设置
+	Settings *RoomSettings `json:"settings,omitempty"`
 }
 
 type CreateRoomResponse struct {
 	RoomID string `json:"room_id"`
+	// 房主凭证，仅返回给创建者，携带该凭证加入房间即可获得管理员席位
+	CreatorToken string `json:"creator_token"`
+}
+
+type UploadDeckRequest struct {
+	Name string `json:"name"`
+	// 为空表示上传到全服词库，否则上传到该房间，需要提供房主凭证
+	RoomID       string `json:"room_id"`
+	CreatorToken string `json:"-"`
+	// 上传全服词库时需要提供服务器配置的上传凭证
+	UploadToken string `json:"-"`
+	// json、yaml 或 csv
+	Format string `json:"format"`
+}
+
+type ListDecksResponse struct {
+	Decks []wordbank.DeckInfo `json:"decks"`
+}
+
+type ListRoomsRequest struct {
+	Page     int    `json:"page"`
+	PageSize int    `json:"page_size"`
+	Name     string `json:"name"`
+	Stage    string `json:"stage"`
+}
+
+type ListRoomsResponse struct {
+	Rooms    []RoomSummary `json:"rooms"`
+	Total    int           `json:"total"`
+	Page     int           `json:"page"`
+	PageSize int           `json:"page_size"`
+}
+
+// RoomSummary 是房间的公开摘要，由状态机在事件循环内生成，供大厅列表读取
+type RoomSummary struct {
+	RoomID        string    `json:"room_id"`
+	RoomName      string    `json:"room_name"`
+	CreatorName   string    `json:"creator_name"`
+	Stage         string    `json:"stage"`
+	MaxPlayers    int       `json:"max_players"`
+	AliveCount    int       `json:"alive_count"`
+	ObserverCount int       `json:"observer_count"`
+	CreatedAt     time.Time `json:"created_at"`
+
+	// 以下字段仅供房间回收器使用，不对外暴露
+	PlayerCount int       `json:"-"`
+	OnlineCount int       `json:"-"`
+	IdleSince   time.Time `json:"-"`
 }
 
 type JoinGameRequest struct {
 	RoomID     string `json:"room_id"`
 	JoinerName string `json:"joiner_name"`
-	// Optional client-supplied player ID for reconnecting
-	PlayerID string `json:"player_id,omitempty"`
+	// Optional reconnect token returned by a previous JoinGame, resumes that seat
+	ReconnectToken string `json:"reconnect_token,omitempty"`
 	// Optional explicit observer intent from client
-	Observer bool                 `json:"observer,omitempty"`
-	RespCh   chan ResponseWrapper `json:"-"`
+	Observer bool `json:"observer,omitempty"`
+	// Optional creator token returned by CreateRoom, grants the admin seat
+	CreatorToken string               `json:"creator_token,omitempty"`
+	RespCh       chan ResponseWrapper `json:"-"`
+	// 由连接层写入的客户端 IP
+	RemoteIP string `json:"-"`
 }
 
 type JoinGameResponse struct {
-	RoomID   string   `json:"room_id"`
-	Stage    string   `json:"stage"`
-	Joiner   Player   `json:"joiner"`
-	Players  []Player `json:"players"`
-	MasterID string   `json:"master_id"`
+	RoomID   string       `json:"room_id"`
+	Stage    string       `json:"stage"`
+	Joiner   Player       `json:"joiner"`
+	Players  []Player     `json:"players"`
+	MasterID string       `json:"master_id"`
+	CoHostID string       `json:"co_host_id,omitempty"`
+	Paused   bool         `json:"paused,omitempty"`
+	Settings RoomSettings `json:"settings"`
+	// 重连凭证，只出现在发给加入者本人的响应中
+	ReconnectToken string `json:"reconnect_token,omitempty"`
 }
 
 type SetWordsRequest struct {
@@ -32,11 +97,64 @@ type SetWordsRequest struct {
 	WordList    []string `json:"word_list"`
 }
 
+type PickWordsRequest struct {
+	ReqPlayerID string `json:"req_player_id"`
+	Category    string `json:"category"`
+	Difficulty  string `json:"difficulty"`
+	Language    string `json:"language"`
+}
+
+// PickWordsResponse 只公开筛选条件，不包含任何词语
+type PickWordsResponse struct {
+	Category   string `json:"category"`
+	Difficulty string `json:"difficulty"`
+	Language   string `json:"language"`
+}
+
+type SelectDeckRequest struct {
+	ReqPlayerID string `json:"req_player_id"`
+	DeckID      string `json:"deck_id"`
+}
+
+type SelectDeckResponse struct {
+	Deck wordbank.DeckInfo `json:"deck"`
+}
+
+type GuessWordRequest struct {
+	ReqPlayerID string `json:"req_player_id"`
+	Guess       string `json:"guess"`
+}
+
+// GuessWordPrompt 单播给出局的卧底或白板，提示其在限时内猜平民词
+type GuessWordPrompt struct {
+	PlayerID         string `json:"player_id"`
+	PlayerName       string `json:"player_name"`
+	RemainingSeconds int    `json:"remaining_seconds"`
+}
+
+// GuessResultNotification 广播最终猜词的结果，猜错时不公开猜测的词语
+type GuessResultNotification struct {
+	PlayerID   string `json:"player_id"`
+	PlayerName string `json:"player_name"`
+	Correct    bool   `json:"correct"`
+	TimedOut   bool   `json:"timed_out,omitempty"`
+	Guess      string `json:"guess,omitempty"`
+}
+
 type SetWordsResponse struct {
 	// 为保持游戏悬念，服务器不返回实际词语，只返回空数组表示设置成功
 	WordList []string `json:"word_list"`
 }
 
+type UpdateSettingsRequest struct {
+	ReqPlayerID string       `json:"req_player_id"`
+	Settings    RoomSettings `json:"settings"`
+}
+
+type UpdateSettingsResponse struct {
+	Settings RoomSettings `json:"settings"`
+}
+
 type StartGameRequest struct {
 	StartPlayerID string `json:"start_player_id"`
 }
@@ -56,11 +174,25 @@ type DescribeResponse struct {
 	SpeakerID   string `json:"speaker_id"`
 	SpeakerName string `json:"speaker_name"`
 	Message     string `json:"message"`
+	// 发言中的词语已被替换为 *
+	Masked bool `json:"masked,omitempty"`
+}
+
+// WordLeakNotification 发言中出现了词语：拒绝时只单播给发言者，淘汰时广播给所有人，不包含发言内容
+type WordLeakNotification struct {
+	SpeakerID   string `json:"speaker_id"`
+	SpeakerName string `json:"speaker_name"`
+	Policy      string `json:"policy"`
 }
 
+// VOTE_ABSTAIN 是弃权票在投票记录中的目标值
+const VOTE_ABSTAIN = ""
+
 type VoteRequest struct {
 	VoterID  string `json:"voter_id"`
 	TargetID string `json:"target_id"`
+	// 弃权：为 true 时忽略 TargetID，弃权票计入已投票人数但不计入任何人的得票
+	Abstain bool `json:"abstain,omitempty"`
 }
 
 type VoteResponse struct {
@@ -68,6 +200,28 @@ type VoteResponse struct {
 	VoterName  string `json:"voter_name"`
 	TargetID   string `json:"target_id"`
 	TargetName string `json:"target_name"`
+	Abstain    bool   `json:"abstain"`
+	// 允许改票时，表示该投票覆盖了之前的投票
+	Changed bool `json:"changed,omitempty"`
+}
+
+// VoteProgressNotification 不记名投票时代替 VoteResponse 广播的投票进度
+type VoteProgressNotification struct {
+	Voted int `json:"voted"`
+	Total int `json:"total"`
+}
+
+// VoteRevealNotification 不记名投票在判定时公开的完整投票表
+type VoteRevealNotification struct {
+	Round   int            `json:"round"`
+	Runoff  bool           `json:"runoff"`
+	Ballots []VoteResponse `json:"ballots"`
+}
+
+// PlayerRef 只包含玩家 ID 和名称，用于不应暴露身份的通知
+type PlayerRef struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
 }
 
 type GameStateNotification struct {
@@ -75,6 +229,9 @@ type GameStateNotification struct {
 	CurrentTurnID   string `json:"current_turn_id,omitempty"`
 	CurrentTurnName string `json:"current_turn_name,omitempty"`
 	Round           int    `json:"round"`
+	// 加时赛（PK）：平票玩家再次发言，其余存活玩家只能在平票玩家中投票
+	Runoff           bool        `json:"runoff"`
+	RunoffCandidates []PlayerRef `json:"runoff_candidates,omitempty"`
 }
 
 type EliminateNotification struct {
@@ -83,21 +240,262 @@ type EliminateNotification struct {
 	EliminatedWord string `json:"eliminated_word"`
 }
 
+// 本轮无人出局的原因
+const (
+	// 加时赛后仍然平票，且平票处理策略为无人出局
+	NO_ELIMINATION_TIE = "tie"
+	// 最高票未达到房间设置的淘汰票数
+	NO_ELIMINATION_QUORUM = "quorum"
+)
+
+type NoEliminationNotification struct {
+	Reason         string      `json:"reason"`
+	TiedCandidates []PlayerRef `json:"tied_candidates,omitempty"`
+	// 本轮最高得票数，以及淘汰所需的最少票数
+	MaxVotes int `json:"max_votes"`
+	Quorum   int `json:"quorum"`
+	Round    int `json:"round"`
+}
+
+// 本轮平票的处理方式，加时赛后取值为实际执行的平票处理策略（none/random/all），无人出局时为 none
+const (
+	// 没有平票
+	TIE_BREAK_NONE = ""
+	// 首次平票，进入加时赛
+	TIE_BREAK_RUNOFF = "runoff"
+)
+
+type CandidateCount struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Votes int    `json:"votes"`
+}
+
+// RoundSummary 判定阶段广播的本轮投票汇总
+type RoundSummary struct {
+	Round  int  `json:"round"`
+	Runoff bool `json:"runoff"`
+	// 每位候选玩家的得票数，按得票从高到低排序
+	Counts      []CandidateCount `json:"counts"`
+	Abstentions int              `json:"abstentions"`
+	Quorum      int              `json:"quorum"`
+	// 投票表，不记名投票时省略（已通过 VoteReveal 公开）
+	Ballots        []VoteResponse `json:"ballots,omitempty"`
+	TiedCandidates []PlayerRef    `json:"tied_candidates,omitempty"`
+	TieBreak       string         `json:"tie_break,omitempty"`
+	Eliminated     []PlayerRef    `json:"eliminated"`
+	// 本轮无人出局时的原因（tie/quorum），与 NoElimination 通知一致
+	NoEliminationReason string `json:"no_elimination_reason,omitempty"`
+}
+
+// 游戏结束原因
+const (
+	FINISH_REASON_NORMAL     = "normal"
+	FINISH_REASON_TIME_LIMIT = "time_limit"
+	// 玩家中途离开后胜负已分
+	FINISH_REASON_PLAYER_LEFT = "player_left"
+	// 管理员中止游戏，没有胜利方
+	FINISH_REASON_ABORTED = "aborted"
+	// 发言者泄露词语被淘汰后胜负已分
+	FINISH_REASON_WORD_LEAK = "word_leak"
+	// 出局的卧底或白板猜中平民词，卧底方获胜
+	FINISH_REASON_FINAL_GUESS = "final_guess"
+)
+
 type GameResultResponse struct {
+	// 管理员中止游戏时为空
 	Winner      string            `json:"winner"`
+	Reason      string            `json:"reason"`
 	AnswerWord  string            `json:"answer_word"`
 	SpyWord     string            `json:"spy_word"`
 	PlayerRoles map[string]string `json:"player_roles"`
 	PlayerWords map[string]string `json:"player_words"`
 }
 
+// 超时事件类型
+const (
+	// 阶段定时器超时
+	TIMEOUT_STAGE = "Stage"
+	// 房主宽限期结束
+	TIMEOUT_CREATOR_GRACE = "CreatorGrace"
+	// 全局游戏时钟到期
+	TIMEOUT_GAME_CLOCK = "GameClock"
+	// 玩家断线宽限期结束
+	TIMEOUT_DISCONNECT_GRACE = "DisconnectGrace"
+)
+
 type TimeoutRequest struct {
 	Stage string `json:"stage"`
+	Kind  string `json:"kind"`
+	// 与玩家相关的超时（如断线宽限期）携带玩家 ID
+	PlayerID string `json:"player_id,omitempty"`
+	// 阶段定时器的序号，与当前序号不一致的超时事件已经过期
+	Seq uint64 `json:"seq,omitempty"`
 }
 
 type ExitGameRequest struct {
-	PlayerID string               `json:"player_id"`
-	RespCh   chan ResponseWrapper `json:"-"`
+	PlayerID string `json:"player_id"`
+	// 由连接层在网络断开时置位，区别于客户端主动发送的 ExitGame
+	Disconnected bool `json:"-"`
+	// 断开的连接对应的响应通道，用于识别已被重连顶替的旧连接
+	RespCh chan ResponseWrapper `json:"-"`
+}
+
+type PlayerDisconnectedNotification struct {
+	PlayerID     string `json:"player_id"`
+	PlayerName   string `json:"player_name"`
+	GraceSeconds int    `json:"grace_seconds"`
+}
+
+type RematchRequest struct {
+	ReqPlayerID string `json:"req_player_id"`
+}
+
+type RematchOptOutRequest struct {
+	ReqPlayerID string `json:"req_player_id"`
+}
+
+type RematchNotification struct {
+	// false 表示管理员刚发起再来一局，窗口期内可退出；true 表示房间已回到等待阶段
+	Started       bool     `json:"started"`
+	WindowSeconds int      `json:"window_seconds,omitempty"`
+	OptedOutIDs   []string `json:"opted_out_ids"`
+	Players       []Player `json:"players,omitempty"`
+	MasterID      string   `json:"master_id"`
+}
+
+type RematchOptOutResponse struct {
+	PlayerID   string `json:"player_id"`
+	PlayerName string `json:"player_name"`
+}
+
+// 管理员变更原因
+const (
+	// 房主宽限期内未加入，由最早加入的玩家代理
+	MASTER_CHANGE_CREATOR_ABSENT = "creator_absent"
+	// 管理员离开房间，自动移交给副管理员或最早加入的在线玩家
+	MASTER_CHANGE_ADMIN_LEFT = "admin_left"
+	// 管理员主动移交
+	MASTER_CHANGE_TRANSFER = "transfer"
+	// 管理员设置或取消了副管理员，管理员本身不变
+	MASTER_CHANGE_CO_HOST = "co_host"
+)
+
+type MasterChangedNotification struct {
+	// 房间内没有可接任的玩家时为空，之后首个加入等待阶段的玩家成为管理员
+	MasterID   string `json:"master_id"`
+	MasterName string `json:"master_name"`
+	CoHostID   string `json:"co_host_id"`
+	Reason     string `json:"reason"`
+}
+
+type TransferAdminRequest struct {
+	ReqPlayerID string `json:"req_player_id"`
+	TargetID    string `json:"target_id"`
+}
+
+type SetCoHostRequest struct {
+	ReqPlayerID string `json:"req_player_id"`
+	// 为空表示取消副管理员
+	TargetID string `json:"target_id"`
+}
+
+type KickPlayerRequest struct {
+	ReqPlayerID string `json:"req_player_id"`
+	TargetID    string `json:"target_id"`
+}
+
+type BanPlayerRequest struct {
+	ReqPlayerID string `json:"req_player_id"`
+	TargetID    string `json:"target_id"`
+}
+
+type MutePlayerRequest struct {
+	ReqPlayerID string `json:"req_player_id"`
+	TargetID    string `json:"target_id"`
+	// false 表示解除禁言
+	Muted bool `json:"muted"`
+}
+
+// 管理操作类型
+const (
+	MODERATION_KICK   = "kick"
+	MODERATION_BAN    = "ban"
+	MODERATION_MUTE   = "mute"
+	MODERATION_UNMUTE = "unmute"
+)
+
+type ModerationNotification struct {
+	Action     string `json:"action"`
+	TargetID   string `json:"target_id"`
+	TargetName string `json:"target_name"`
+}
+
+type PauseGameRequest struct {
+	ReqPlayerID string `json:"req_player_id"`
+}
+
+type ResumeGameRequest struct {
+	ReqPlayerID string `json:"req_player_id"`
+}
+
+type GamePausedNotification struct {
+	Paused bool   `json:"paused"`
+	Stage  string `json:"stage"`
+	// 当前阶段剩余的秒数（向上取整），当前阶段没有计时时为 0
+	RemainingSeconds int `json:"remaining_seconds"`
+}
+
+type SkipSpeakerRequest struct {
+	ReqPlayerID string `json:"req_player_id"`
+}
+
+type EndVotingRequest struct {
+	ReqPlayerID string `json:"req_player_id"`
+}
+
+type ExtendTimerRequest struct {
+	ReqPlayerID string `json:"req_player_id"`
+	Seconds     int    `json:"seconds"`
+}
+
+type AbortGameRequest struct {
+	ReqPlayerID string `json:"req_player_id"`
+}
+
+// 管理员干预游戏流程的操作类型
+const (
+	OVERRIDE_SKIP_SPEAKER = "skip_speaker"
+	OVERRIDE_END_VOTING   = "end_voting"
+	OVERRIDE_EXTEND_TIMER = "extend_timer"
+	OVERRIDE_ABORT        = "abort"
+)
+
+type AdminOverrideNotification struct {
+	Action string `json:"action"`
+	Stage  string `json:"stage"`
+	// 被跳过的发言者，仅 skip_speaker 时携带
+	Target *PlayerRef `json:"target,omitempty"`
+	// 延长的秒数和延长后的剩余秒数，仅 extend_timer 时携带
+	ExtendSeconds    int `json:"extend_seconds,omitempty"`
+	RemainingSeconds int `json:"remaining_seconds,omitempty"`
+}
+
+type SetReadyRequest struct {
+	ReqPlayerID string `json:"req_player_id"`
+	Ready       bool   `json:"ready"`
+}
+
+type SetReadyResponse struct {
+	PlayerID   string `json:"player_id"`
+	PlayerName string `json:"player_name"`
+	Ready      bool   `json:"ready"`
+}
+
+type AutoStartNotification struct {
+	// true 表示开始倒计时，false 表示倒计时已取消
+	Started bool `json:"started"`
+	Seconds int  `json:"seconds,omitempty"`
 }
 
 type ExitGameResponse struct {
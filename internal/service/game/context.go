@@ -1,44 +1,144 @@
 package game
 
 import (
+	"crypto/subtle"
+	"slices"
 	"time"
 
+	"who-is-spy-be/internal/service/wordbank"
+
 	"go.uber.org/zap"
 )
 
 type GameContext struct {
-	RoomID    string
-	GameStage string
-	Players   map[string]*Player
+	RoomID      string
+	RoomName    string
+	CreatorName string
+	GameStage   string
+	Players     map[string]*Player
+	Settings    RoomSettings
 
 	Answer     string
 	SpyWord    string
 	AnswerWord string
 	WordList   []string
 
+	// 内置词库；管理员通过 PickWords 选择筛选条件后，开始游戏时由服务端抽取词对
+	WordBank   *wordbank.Bank
+	WordFilter *wordbank.Filter
+	// 本房间已经玩过的词对，抽词时跳过
+	PlayedPairs map[string]bool
+	// 词库仓库，以及管理员通过 SelectDeck 选择的词库 ID（为空表示内置词库）
+	Decks  *wordbank.Store
+	DeckID string
+
 	Round             int
 	SpeakingOrder     []string
 	CurrentSpeakerIdx int
-	Votes             map[string]string
+	// 投票记录：投票者 ID -> 被投票者 ID，弃权记为 VOTE_ABSTAIN
+	Votes map[string]string
+	// 加时赛（PK）中的平票玩家，为空表示不在加时赛中
+	RunoffCandidates []string
+	// 等待最终猜词的出局卧底/白板，队首为当前猜词者
+	FinalGuessers []string
+
+	// 全局游戏时钟，从准备阶段开始计时，到期后以超时结果结算
+	GameTimer    *time.Timer
+	GameDeadline time.Time
+	// 结束原因，为空时视为正常结束
+	FinishReason string
+
+	// 等待阶段的自动开始倒计时是否进行中，倒计时使用阶段定时器
+	AutoStartPending bool
+
+	// 再来一局：是否处于退出窗口期，以及选择退出下一局的玩家
+	RematchPending bool
+	RematchOptOuts map[string]bool
+	// 房间关闭标记：结束阶段无人发起再来一局时置位，事件循环随后退出
+	Closed bool
+
+	// 当前管理员与副管理员的玩家 ID；管理员离开时优先移交给在线的副管理员
+	AdminID  string
+	CoHostID string
+
+	// 被封禁的玩家 ID 和客户端 IP，在房间存续期间不能再加入
+	BannedIDs map[string]bool
+	BannedIPs map[string]bool
+
+	// 房主凭证与宽限期：宽限期内管理员席位只保留给持有凭证的房主
+	CreatorToken        string
+	CreatorGrace        time.Duration
+	CreatorGraceExpired bool
+	CreatorTimer        *time.Timer
+	// 持有房主凭证加入的玩家 ID，房主再次携带凭证加入时恢复该席位
+	CreatorID string
 
-	Timer *time.Timer
-	TmoCh chan RequestWrapper
+	// 签发重连凭证的密钥，由房间服务统一提供
+	ReconnectKey []byte
+
+	// 断线宽限期：期间保留玩家的身份、词语和席位（<= 0 表示断线即离开）
+	DisconnectGrace  time.Duration
+	DisconnectTimers map[string]*time.Timer
+
+	// 暂停：暂停期间所有定时器停止，记录阶段定时器和全局游戏时钟的剩余时长（< 0 表示暂停时未启用）
+	Paused          bool
+	PausedAt        time.Time
+	PausedStageLeft time.Duration
+	PausedClockLeft time.Duration
+
+	Timer         *time.Timer
+	TimerDeadline time.Time
+	// 阶段定时器序号，每次设置或清除定时器时递增，用于丢弃已投递到通道中的过期超时事件
+	TimerSeq uint64
+	TmoCh    chan RequestWrapper
 }
 
+// GetAdmin 返回当前管理员，没有管理员时返回 nil。
+// 等待阶段的管理员占用 ROLE_ADMIN 席位；游戏中接任的管理员保留自己的参与者身份
 func (gc *GameContext) GetAdmin() *Player {
-	for _, p := range gc.Players {
-		if p.Role == ROLE_ADMIN {
-			return p
-		}
+	if gc.AdminID == "" {
+		return nil
 	}
 
-	return nil
+	return gc.Players[gc.AdminID]
+}
+
+// MasterID 返回当前管理员 ID，没有管理员时返回空字符串
+func (gc *GameContext) MasterID() string {
+	if admin := gc.GetAdmin(); admin != nil {
+		return admin.ID
+	}
+
+	return ""
+}
+
+// IsCreatorToken 判断给定凭证是否为本房间的房主凭证
+func (gc *GameContext) IsCreatorToken(token string) bool {
+	if gc.CreatorToken == "" || token == "" {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(gc.CreatorToken), []byte(token)) == 1
+}
+
+// ReconnectToken 返回玩家在本房间的重连凭证
+func (gc *GameContext) ReconnectToken(playerID string) string {
+	if len(gc.ReconnectKey) == 0 {
+		return ""
+	}
+
+	return signReconnectToken(gc.ReconnectKey, gc.RoomID, playerID)
 }
 
 func (gc *GameContext) BroadcastResp(resp ResponseWrapper) {
+	gc.BroadcastRespExcept(resp, "")
+}
+
+// BroadcastRespExcept 向除指定玩家以外的所有玩家广播响应
+func (gc *GameContext) BroadcastRespExcept(resp ResponseWrapper, exceptID string) {
 	for _, p := range gc.Players {
 		// skip players without a response channel (disconnected / cleaned-up)
-		if p.RespCh == nil {
+		if p.RespCh == nil || p.ID == exceptID {
 			continue
 		}
 
@@ -72,6 +172,7 @@ func (gc *GameContext) UnicastResp(playerID string, resp ResponseWrapper) {
 			"无法找到玩家进行单播响应",
 			zap.String("player_id", playerID),
 		)
+		return
 	}
 
 	select {
@@ -131,41 +232,230 @@ func (gc *GameContext) IsBlankAlive() bool {
 	return false
 }
 
+// InRunoff 当前是否处于加时赛（PK）
+func (gc *GameContext) InRunoff() bool {
+	return len(gc.RunoffCandidates) > 0
+}
+
+// IsRunoffCandidate 判断玩家是否为加时赛中的平票玩家
+func (gc *GameContext) IsRunoffCandidate(playerID string) bool {
+	return slices.Contains(gc.RunoffCandidates, playerID)
+}
+
+// RunoffRefs 返回加时赛平票玩家的 ID 和名称
+func (gc *GameContext) RunoffRefs() []PlayerRef {
+	return gc.playerRefs(gc.RunoffCandidates)
+}
+
+func (gc *GameContext) playerRefs(playerIDs []string) []PlayerRef {
+	refs := make([]PlayerRef, 0, len(playerIDs))
+	for _, id := range playerIDs {
+		if p, ok := gc.Players[id]; ok {
+			refs = append(refs, PlayerRef{ID: p.ID, Name: p.Name})
+		}
+	}
+
+	return refs
+}
+
 func (gc *GameContext) SetTimeout(duration time.Duration) {
 	// 清除之前的定时器
 	gc.ClearTimeout()
 
-	// 创建新的定时器
-	gc.Timer = time.AfterFunc(duration, func() {
-		// 构造超时请求
-		timeoutReq := TimeoutRequest{
-			Stage: gc.GameStage,
-		}
+	// 暂停期间只记录时长，恢复时再启动
+	if gc.Paused {
+		gc.PausedStageLeft = duration
+		return
+	}
 
-		// 将超时请求包装并发送到请求通道
-		wrapper := RequestWrapper{
-			ReqType: REQ_TIMEOUT,
-			Data:    mustMarshal(timeoutReq),
-		}
+	// 创建新的定时器
+	gc.TimerDeadline = time.Now().Add(duration)
 
-		select {
-		case gc.TmoCh <- wrapper:
-			zap.L().Debug(
-				"超时事件已发送",
-				zap.String("stage", gc.GameStage),
-			)
-		default:
-			zap.L().Warn(
-				"超时事件发送失败：请求通道已满",
-				zap.String("stage", gc.GameStage),
-			)
-		}
+	stage := gc.GameStage
+	seq := gc.TimerSeq
+	gc.Timer = time.AfterFunc(duration, func() {
+		gc.sendTimeout(TimeoutRequest{
+			Stage: stage,
+			Kind:  TIMEOUT_STAGE,
+			Seq:   seq,
+		})
 	})
 }
 
 func (gc *GameContext) ClearTimeout() {
+	// Stop 无法撤回已经投递到通道中的超时事件，递增序号使其失效
+	gc.TimerSeq++
+
 	if gc.Timer != nil {
 		gc.Timer.Stop()
 		gc.Timer = nil
 	}
+
+	if gc.Paused {
+		gc.PausedStageLeft = -1
+	}
+}
+
+// StartCreatorGrace 启动房主宽限期定时器，与阶段定时器相互独立
+func (gc *GameContext) StartCreatorGrace(duration time.Duration) {
+	gc.StopCreatorGrace()
+
+	stage := gc.GameStage
+	gc.CreatorTimer = time.AfterFunc(duration, func() {
+		gc.sendTimeout(TimeoutRequest{
+			Stage: stage,
+			Kind:  TIMEOUT_CREATOR_GRACE,
+		})
+	})
+}
+
+func (gc *GameContext) StopCreatorGrace() {
+	if gc.CreatorTimer != nil {
+		gc.CreatorTimer.Stop()
+		gc.CreatorTimer = nil
+	}
+}
+
+// StartGameClock 启动全局游戏时钟，与阶段定时器相互独立
+func (gc *GameContext) StartGameClock(duration time.Duration) {
+	gc.StopGameClock()
+
+	if gc.Paused {
+		gc.PausedClockLeft = duration
+		return
+	}
+
+	gc.GameDeadline = time.Now().Add(duration)
+
+	stage := gc.GameStage
+	gc.GameTimer = time.AfterFunc(duration, func() {
+		gc.sendTimeout(TimeoutRequest{
+			Stage: stage,
+			Kind:  TIMEOUT_GAME_CLOCK,
+		})
+	})
+}
+
+func (gc *GameContext) StopGameClock() {
+	if gc.GameTimer != nil {
+		gc.GameTimer.Stop()
+		gc.GameTimer = nil
+	}
+
+	if gc.Paused {
+		gc.PausedClockLeft = -1
+	}
+}
+
+// StartDisconnectGrace 启动玩家的断线宽限期定时器
+func (gc *GameContext) StartDisconnectGrace(playerID string) {
+	gc.startDisconnectGrace(playerID, gc.DisconnectGrace)
+}
+
+func (gc *GameContext) startDisconnectGrace(playerID string, duration time.Duration) {
+	gc.StopDisconnectGrace(playerID)
+
+	// 暂停期间不计时，恢复时按剩余时长启动
+	if gc.Paused {
+		return
+	}
+
+	if gc.DisconnectTimers == nil {
+		gc.DisconnectTimers = make(map[string]*time.Timer)
+	}
+
+	stage := gc.GameStage
+	gc.DisconnectTimers[playerID] = time.AfterFunc(duration, func() {
+		gc.sendTimeout(TimeoutRequest{
+			Stage:    stage,
+			Kind:     TIMEOUT_DISCONNECT_GRACE,
+			PlayerID: playerID,
+		})
+	})
+}
+
+func (gc *GameContext) StopDisconnectGrace(playerID string) {
+	if timer, ok := gc.DisconnectTimers[playerID]; ok {
+		timer.Stop()
+		delete(gc.DisconnectTimers, playerID)
+	}
+}
+
+// PauseTimers 停止阶段定时器、全局游戏时钟和断线宽限期定时器，并记录剩余时长
+func (gc *GameContext) PauseTimers() {
+	if gc.Paused {
+		return
+	}
+
+	stageLeft := time.Duration(-1)
+	if gc.Timer != nil {
+		stageLeft = max(time.Until(gc.TimerDeadline), 0)
+	}
+
+	clockLeft := time.Duration(-1)
+	if gc.GameTimer != nil {
+		clockLeft = max(time.Until(gc.GameDeadline), 0)
+	}
+
+	gc.ClearTimeout()
+	gc.StopGameClock()
+
+	for playerID := range gc.DisconnectTimers {
+		gc.StopDisconnectGrace(playerID)
+	}
+
+	gc.Paused = true
+	gc.PausedAt = time.Now()
+	gc.PausedStageLeft = stageLeft
+	gc.PausedClockLeft = clockLeft
+}
+
+// ResumeTimers 按暂停时记录的剩余时长重新启动所有定时器
+func (gc *GameContext) ResumeTimers() {
+	if !gc.Paused {
+		return
+	}
+
+	gc.Paused = false
+
+	if gc.PausedStageLeft >= 0 {
+		gc.SetTimeout(gc.PausedStageLeft)
+	}
+
+	if gc.PausedClockLeft >= 0 {
+		gc.StartGameClock(gc.PausedClockLeft)
+	}
+
+	// 断线时间顺延暂停的时长（暂停期间断线的玩家从断线时算起），宽限期超时检查依赖该时间
+	for _, p := range gc.Players {
+		if !p.Disconnected {
+			continue
+		}
+
+		p.DisconnectedAt = p.DisconnectedAt.Add(time.Since(latest(gc.PausedAt, p.DisconnectedAt)))
+		gc.startDisconnectGrace(p.ID, max(gc.DisconnectGrace-time.Since(p.DisconnectedAt), 0))
+	}
+}
+
+// sendTimeout 将超时事件包装后投递到超时通道，由事件循环串行处理
+func (gc *GameContext) sendTimeout(timeoutReq TimeoutRequest) {
+	wrapper := RequestWrapper{
+		ReqType: REQ_TIMEOUT,
+		Data:    mustMarshal(timeoutReq),
+	}
+
+	select {
+	case gc.TmoCh <- wrapper:
+		zap.L().Debug(
+			"超时事件已发送",
+			zap.String("stage", timeoutReq.Stage),
+			zap.String("kind", timeoutReq.Kind),
+		)
+	default:
+		zap.L().Warn(
+			"超时事件发送失败：请求通道已满",
+			zap.String("stage", timeoutReq.Stage),
+			zap.String("kind", timeoutReq.Kind),
+		)
+	}
 }
@@ -1,8 +1,11 @@
 package game
 
 import (
+	"sync"
 	"time"
 
+	"who-is-spy-be/internal/service/wordbank"
+
 	"go.uber.org/zap"
 )
 
@@ -16,12 +19,59 @@ type GameMachine struct {
 	doneCh chan struct{}
 
 	createdAt time.Time
+
+	// 房间摘要只在事件循环内写入，外部通过 Summary 读取副本
+	summaryMu sync.RWMutex
+	summary   RoomSummary
+	// 所有玩家均断线的起始时间，有玩家在线时为零值
+	idleSince time.Time
+}
+
+// RoomMeta 是创建房间时确定的元信息
+type RoomMeta struct {
+	RoomID      string
+	RoomName    string
+	CreatorName string
+	Settings    RoomSettings
+
+	// 房主凭证，以及房主未加入时保留管理员席位的宽限期（<= 0 表示不启用兜底）
+	CreatorToken string
+	CreatorGrace time.Duration
+
+	// 签发重连凭证的密钥
+	ReconnectKey []byte
+	// 断线宽限期（<= 0 表示断线即离开）
+	DisconnectGrace time.Duration
+
+	// 内置词库，为空时只能手动设置词语
+	WordBank *wordbank.Bank
+	// 词库仓库，SelectDeck 从中选择全服词库或本房间上传的词库
+	Decks *wordbank.Store
 }
 
-func NewGameMachine(roomID string, doneCh chan struct{}) *GameMachine {
+func NewGameMachine(meta RoomMeta, doneCh chan struct{}) *GameMachine {
 	ctx := &GameContext{
-		RoomID: roomID,
-		TmoCh:  make(chan RequestWrapper, 64),
+		RoomID:      meta.RoomID,
+		RoomName:    meta.RoomName,
+		CreatorName: meta.CreatorName,
+		GameStage:   STAGE_WAITING,
+		Settings:    meta.Settings,
+
+		CreatorToken: meta.CreatorToken,
+		CreatorGrace: meta.CreatorGrace,
+		ReconnectKey: meta.ReconnectKey,
+
+		DisconnectGrace:  meta.DisconnectGrace,
+		DisconnectTimers: make(map[string]*time.Timer),
+
+		BannedIDs: make(map[string]bool),
+		BannedIPs: make(map[string]bool),
+
+		WordBank:    meta.WordBank,
+		Decks:       meta.Decks,
+		PlayedPairs: make(map[string]bool),
+
+		TmoCh: make(chan RequestWrapper, 64),
 	}
 
 	reqCh := make(chan RequestWrapper, 64)
@@ -41,6 +91,12 @@ func NewGameMachine(roomID string, doneCh chan struct{}) *GameMachine {
 
 	gm.handler.SetOnSwitch(onSwitch)
 
+	if ctx.Settings.BlindHost {
+		applyBlindHost(ctx)
+	}
+
+	gm.publishSummary()
+
 	return gm
 }
 
@@ -53,12 +109,12 @@ func (gm *GameMachine) Start() {
 	gm.handler.OnEnter(gm.ctx)
 
 	// 处理 OnEnter 内部触发的阶段切换，避免等待下一次请求才切换
-	if gm.alignStageAfterEnter("init") {
-		return
-	}
+	gm.alignStageAfterEnter("init")
 
-	// 进入事件循环
-	for {
+	gm.publishSummary()
+
+	// 进入事件循环，直到房间被关闭（结束阶段无人发起再来一局）
+	for !gm.ctx.Closed {
 		// 从请求通道或超时通道接收事件
 		var req RequestWrapper
 
@@ -79,11 +135,29 @@ func (gm *GameMachine) Start() {
 				"收到退出信号，结束游戏状态机",
 				zap.String("room_id", gm.ctx.RoomID),
 			)
+			gm.shutdown()
 			return
 		}
 
-		// 处理请求
-		err := gm.handler.OnHandle(gm.ctx, req)
+		// 拒绝身份与连接不一致的请求，避免客户端冒充其他玩家
+		if err := verifySender(&req); err != nil {
+			zap.L().Warn(
+				"拒绝伪造身份的请求",
+				zap.String("room_id", gm.ctx.RoomID),
+				zap.String("sender_id", req.SenderID),
+				zap.String("request_type", req.ReqType),
+				zap.Error(err),
+			)
+
+			gm.ctx.UnicastResp(req.SenderID, WrapErrResponse(err.Error()))
+			continue
+		}
+
+		// 处理请求：与阶段无关的请求由状态机统一处理，其余交给当前阶段
+		handled, err := handleGlobalRequest(gm.ctx, req)
+		if !handled {
+			err = gm.handler.OnHandle(gm.ctx, req)
+		}
 		if err != nil {
 			zap.L().Debug(
 				"处理请求失败",
@@ -105,34 +179,46 @@ func (gm *GameMachine) Start() {
 			// 状态发生变化，执行切换
 			gm.switchStage()
 
-			// 如果切换到了结束阶段，退出循环
-			if gm.ctx.GameStage == STAGE_FINISHED {
-				zap.L().Info(
-					"状态机：进入结束阶段，准备广播结果",
-					zap.String("room_id", gm.ctx.RoomID),
-				)
-				// 执行结束阶段的 OnEnter
-				gm.handler.OnEnter(gm.ctx)
-				break
-			}
-
 			// 执行新阶段的 OnEnter
 			gm.handler.OnEnter(gm.ctx)
 
 			// 处理 OnEnter 内部触发的阶段切换，避免等待下一次请求才切换
-			if gm.alignStageAfterEnter("post-switch") {
-				break
-			}
+			gm.alignStageAfterEnter("post-switch")
 		}
+
+		// 自动开始倒计时取决于玩家、准备状态和词语，等待阶段的任何事件之后都重新检查
+		refreshAutoStart(gm.ctx)
+
+		gm.publishSummary()
 	}
 
-	// 游戏结束后，协程应当自动退出，释放资源
+	// 房间关闭后，协程应当自动退出，释放资源
+	gm.shutdown()
+
 	zap.L().Info(
 		"游戏状态机已结束",
 		zap.String("room_id", gm.ctx.RoomID),
 	)
 }
 
+// shutdown 在事件循环退出时清理定时器，并关闭所有仍在线玩家的响应通道，通知写协程退出
+func (gm *GameMachine) shutdown() {
+	gm.ctx.ClearTimeout()
+	gm.ctx.StopCreatorGrace()
+	gm.ctx.StopGameClock()
+
+	for playerID := range gm.ctx.DisconnectTimers {
+		gm.ctx.StopDisconnectGrace(playerID)
+	}
+
+	for _, p := range gm.ctx.Players {
+		if p.RespCh != nil {
+			close(p.RespCh)
+			p.RespCh = nil
+		}
+	}
+}
+
 func (gm *GameMachine) switchStage() {
 	// 执行当前 handler 的 OnExit
 	gm.handler.OnExit(gm.ctx)
@@ -173,7 +259,7 @@ func (gm *GameMachine) switchStage() {
 }
 
 // alignStageAfterEnter 处理 OnEnter 内部调用 onSwitch 导致的阶段漂移，确保立即切换而不等待下一次请求
-func (gm *GameMachine) alignStageAfterEnter(reason string) bool {
+func (gm *GameMachine) alignStageAfterEnter(reason string) {
 	for gm.ctx.GameStage != gm.handler.Stage() {
 		zap.L().Info(
 			"状态机：阶段切换（Enter后立即对齐）",
@@ -185,19 +271,8 @@ func (gm *GameMachine) alignStageAfterEnter(reason string) bool {
 
 		gm.switchStage()
 
-		if gm.ctx.GameStage == STAGE_FINISHED {
-			zap.L().Info(
-				"状态机：进入结束阶段，准备广播结果",
-				zap.String("room_id", gm.ctx.RoomID),
-			)
-			gm.handler.OnEnter(gm.ctx)
-			return true
-		}
-
 		gm.handler.OnEnter(gm.ctx)
 	}
-
-	return false
 }
 
 func (gm *GameMachine) IsFinished() bool {
@@ -207,3 +282,50 @@ func (gm *GameMachine) IsFinished() bool {
 func (gm *GameMachine) CreatedAt() time.Time {
 	return gm.createdAt
 }
+
+// Summary 返回最近一次事件处理后的房间摘要，可在事件循环之外安全调用
+func (gm *GameMachine) Summary() RoomSummary {
+	gm.summaryMu.RLock()
+	defer gm.summaryMu.RUnlock()
+
+	return gm.summary
+}
+
+// publishSummary 在事件循环内根据当前上下文生成房间摘要
+func (gm *GameMachine) publishSummary() {
+	observerCount := 0
+	onlineCount := 0
+	for _, p := range gm.ctx.Players {
+		if isObserverLike(p.Role) {
+			observerCount++
+		}
+
+		if p.RespCh != nil {
+			onlineCount++
+		}
+	}
+
+	if onlineCount > 0 {
+		gm.idleSince = time.Time{}
+	} else if gm.idleSince.IsZero() {
+		gm.idleSince = time.Now()
+	}
+
+	summary := RoomSummary{
+		RoomID:        gm.ctx.RoomID,
+		RoomName:      gm.ctx.RoomName,
+		CreatorName:   gm.ctx.CreatorName,
+		Stage:         gm.ctx.GameStage,
+		MaxPlayers:    gm.ctx.Settings.MaxPlayers,
+		AliveCount:    gm.ctx.CountAlive(),
+		ObserverCount: observerCount,
+		CreatedAt:     gm.createdAt,
+		PlayerCount:   len(gm.ctx.Players),
+		OnlineCount:   onlineCount,
+		IdleSince:     gm.idleSince,
+	}
+
+	gm.summaryMu.Lock()
+	gm.summary = summary
+	gm.summaryMu.Unlock()
+}
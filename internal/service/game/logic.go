@@ -2,9 +2,15 @@ package game
 
 import (
 	"errors"
+	"fmt"
 	"math/rand/v2"
+	"slices"
+	"strings"
 	"time"
 
+	"who-is-spy-be/internal/service/wordbank"
+	"who-is-spy-be/internal/service/wordmatch"
+
 	"go.uber.org/zap"
 )
 
@@ -14,7 +20,7 @@ import (
 // 3. 发言阶段（Speaking）：每个玩家轮流发言，其他玩家可以进行猜测
 // 4. 投票阶段（Voting）：玩家对发言者进行投票，选出卧底
 // 5. 判定阶段（Judging）：根据投票结果判定游戏结果，宣布胜利方
-// 6. 结束阶段（Finished）：游戏结束，玩家将离开房间
+// 6. 结束阶段（Finished）：游戏结束，管理员可发起再来一局回到等待阶段，否则房间关闭
 const (
 	STAGE_WAITING   = "Waiting"
 	STAGE_PREPARING = "Preparing"
@@ -24,6 +30,16 @@ const (
 	STAGE_FINISHED  = "Finished"
 )
 
+// IsValidStage 判断给定字符串是否为已知的游戏阶段
+func IsValidStage(stage string) bool {
+	switch stage {
+	case STAGE_WAITING, STAGE_PREPARING, STAGE_SPEAKING, STAGE_VOTING, STAGE_JUDGING, STAGE_FINISHED:
+		return true
+	default:
+		return false
+	}
+}
+
 type StageHandler interface {
 	Stage() string
 
@@ -34,6 +50,25 @@ type StageHandler interface {
 	SetOnSwitch(func(nextStage string))
 }
 
+// onGameClockTimeout 全局游戏时钟到期，无论处于哪个游戏阶段都直接进入结束阶段
+func onGameClockTimeout(ctx *GameContext) {
+	switch ctx.GameStage {
+	case STAGE_PREPARING, STAGE_SPEAKING, STAGE_VOTING, STAGE_JUDGING:
+	default:
+		return
+	}
+
+	zap.L().Info(
+		"全局游戏时钟到期，进入结束阶段",
+		zap.String("roomID", ctx.RoomID),
+		zap.String("stage", ctx.GameStage),
+	)
+
+	ctx.FinishReason = FINISH_REASON_TIME_LIMIT
+	// 直接修改阶段，状态机会在本次事件处理后检测到变化并切换
+	ctx.GameStage = STAGE_FINISHED
+}
+
 // 等待阶段是整个游戏最初始的阶段
 type waitStageHandler struct {
 	onSwitch func(string)
@@ -48,41 +83,83 @@ func (wsh *waitStageHandler) Stage() string {
 }
 
 func (wsh *waitStageHandler) OnEnter(ctx *GameContext) {
-	// 初始化上下文
-	ctx.RoomID = GenID()[len(GenID())-8:] // Generate a short room ID
+	// 初始化上下文（RoomID 由创建房间时确定，这里不再重新生成）
 	ctx.GameStage = STAGE_WAITING
-	ctx.Players = make(map[string]*Player, 0)
 
 	ctx.Answer = ""
 	ctx.WordList = make([]string, 0)
+
+	// 再来一局回到等待阶段时保留玩家名单，只有首次进入时才初始化
+	if ctx.Players != nil {
+		return
+	}
+
+	ctx.Players = make(map[string]*Player, 0)
+
+	// 为房主保留管理员席位，宽限期结束后启用兜底策略
+	if ctx.CreatorGrace > 0 {
+		ctx.StartCreatorGrace(ctx.CreatorGrace)
+	}
 }
 
 func (wsh *waitStageHandler) OnHandle(ctx *GameContext, req RequestWrapper) error {
-	// 在等待阶段只处理 JoinGame、SetWords、StartGame 和 ExitGame 请求
+	// 在等待阶段只处理 JoinGame、SetWords、UpdateSettings、StartGame 和 ExitGame 请求
 	if req := TryUnwrapJoinGameRequest(req); req != nil {
-		playerID := req.PlayerID
-		if playerID == "" {
-			playerID = GenID()[len(GenID())-8:]
+		onJoinRequest(ctx, req)
+		return nil
+	}
+
+	if req := TryUnwrapExitGameRequest(req); req != nil {
+		onPlayerExit(ctx, req)
+		return nil
+	}
+
+	if req := TryUnwrapTimeoutRequest(req); req != nil {
+		if req.Kind == TIMEOUT_CREATOR_GRACE {
+			onCreatorGraceTimeout(ctx)
+			return nil
+		}
+
+		// 自动开始倒计时结束，开始前再确认一次条件
+		if req.Kind == TIMEOUT_STAGE && req.Stage == STAGE_WAITING && ctx.AutoStartPending {
+			ctx.AutoStartPending = false
+
+			if err := checkCanStart(ctx); err != nil {
+				return fmt.Errorf("自动开始失败：%w", err)
+			}
+
+			zap.L().Info("自动开始倒计时结束，开始游戏", zap.String("room_id", ctx.RoomID))
+
+			wsh.onSwitch(STAGE_PREPARING)
+			return nil
 		}
+	}
 
-		player := Player{
-			ID:     playerID,
-			Name:   req.JoinerName,
-			RespCh: req.RespCh,
+	if req := TryUnwrapSetReadyRequest(req); req != nil {
+		player, ok := ctx.Players[req.ReqPlayerID]
+		if !ok {
+			return errors.New("玩家不存在")
 		}
 
-		// 如果客户端显式请求作为观察者，优先保留该身份
-		if req.Observer {
-			player.Role = ROLE_OBSERVER
+		if player.Role != ROLE_UNSET {
+			return errors.New("只有参与游戏的玩家需要准备")
 		}
 
-		onPlayerJoin(ctx, player)
+		if player.Ready == req.Ready {
+			return nil
+		}
 
-		return nil
-	}
+		player.Ready = req.Ready
+
+		ctx.BroadcastResp(WrapResponse(
+			RESP_SET_READY,
+			SetReadyResponse{
+				PlayerID:   player.ID,
+				PlayerName: player.Name,
+				Ready:      player.Ready,
+			},
+		))
 
-	if req := TryUnwrapExitGameRequest(req); req != nil {
-		onPlayerExit(ctx, req.PlayerID, req.RespCh)
 		return nil
 	}
 
@@ -96,6 +173,10 @@ func (wsh *waitStageHandler) OnHandle(ctx *GameContext, req RequestWrapper) erro
 			return errors.New("无法设置词库：只有管理员可以设置词库")
 		}
 
+		if ctx.Settings.BlindHost {
+			return errors.New("无法设置词库：盲主持模式下词语由服务端从词库抽取")
+		}
+
 		// 验证词库必须至少包含两个词：WordList[0] = 正常词，WordList[1] = 卧底词
 		if len(req.WordList) < 2 {
 			return errors.New("无法设置词库：必须提供至少两个词（索引0为正常词，索引1为卧底词）")
@@ -106,8 +187,9 @@ func (wsh *waitStageHandler) OnHandle(ctx *GameContext, req RequestWrapper) erro
 			return errors.New("无法设置词库：正常词和卧底词不能为空")
 		}
 
-		// 更新词库
+		// 更新词库，手动设置的词语优先于内置词库
 		ctx.WordList = req.WordList
+		ctx.WordFilter = nil
 
 		// 发送通知（不广播实际的词语，只通知设置成功）
 		resp := WrapResponse(
@@ -122,6 +204,117 @@ func (wsh *waitStageHandler) OnHandle(ctx *GameContext, req RequestWrapper) erro
 		return nil
 	}
 
+	if req := TryUnwrapPickWordsRequest(req); req != nil {
+		adminPlayer := ctx.GetAdmin()
+		if adminPlayer == nil || adminPlayer.ID != req.ReqPlayerID {
+			return errors.New("无法选择词库：只有管理员可以选择词库")
+		}
+
+		filter := wordbank.Filter{
+			Category:   req.Category,
+			Difficulty: req.Difficulty,
+			Language:   req.Language,
+		}
+
+		// 只确认有可用的词对，真正的词对在开始游戏时抽取，任何人（包括管理员）都不知道
+		if _, err := ctx.WordBank.Pick(filter, ctx.PlayedPairs); err != nil {
+			return fmt.Errorf("无法选择词库：%w", err)
+		}
+
+		ctx.WordFilter = &filter
+		ctx.WordList = make([]string, 0)
+
+		ctx.BroadcastResp(WrapResponse(
+			RESP_PICK_WORDS,
+			PickWordsResponse{
+				Category:   filter.Category,
+				Difficulty: filter.Difficulty,
+				Language:   filter.Language,
+			},
+		))
+
+		return nil
+	}
+
+	if req := TryUnwrapSelectDeckRequest(req); req != nil {
+		adminPlayer := ctx.GetAdmin()
+		if adminPlayer == nil || adminPlayer.ID != req.ReqPlayerID {
+			return errors.New("无法选择词库：只有管理员可以选择词库")
+		}
+
+		if ctx.Decks == nil {
+			return errors.New("无法选择词库：服务器没有可用的词库")
+		}
+
+		deck, err := ctx.Decks.Get(req.DeckID, ctx.RoomID)
+		if err != nil {
+			return fmt.Errorf("无法选择词库：%w", err)
+		}
+
+		// 房间词库由房主上传，盲主持模式下房主会因此知道词语
+		if ctx.Settings.BlindHost && deck.RoomID != "" {
+			return errors.New("无法选择词库：盲主持模式下只能选择全服词库")
+		}
+
+		// 词库中的词对可能都已经玩过
+		if _, err := deck.Bank.Pick(wordbank.Filter{}, ctx.PlayedPairs); err != nil {
+			return fmt.Errorf("无法选择词库：%w", err)
+		}
+
+		// 选择词库后默认从整个词库中抽词，管理员可以再通过 PickWords 缩小范围
+		ctx.WordBank = deck.Bank
+		ctx.DeckID = deck.ID
+		ctx.WordFilter = &wordbank.Filter{}
+		ctx.WordList = make([]string, 0)
+
+		ctx.BroadcastResp(WrapResponse(
+			RESP_SELECT_DECK,
+			SelectDeckResponse{
+				Deck: deck.Info(),
+			},
+		))
+
+		return nil
+	}
+
+	if req := TryUnwrapUpdateSettingsRequest(req); req != nil {
+		adminPlayer := ctx.GetAdmin()
+		if adminPlayer == nil {
+			return errors.New("无法修改设置：当前没有管理员")
+		}
+
+		if adminPlayer.ID != req.ReqPlayerID {
+			return errors.New("无法修改设置：只有管理员可以修改设置")
+		}
+
+		// 未提供的时长和策略项沿用当前设置
+		req.Settings = req.Settings.WithDefaults(ctx.Settings)
+
+		if err := req.Settings.Validate(); err != nil {
+			return fmt.Errorf("无法修改设置：%w", err)
+		}
+
+		if ctx.CountAlive() > req.Settings.MaxPlayers {
+			return errors.New("无法修改设置：当前玩家数已超过新的人数上限")
+		}
+
+		blindHostChanged := ctx.Settings.BlindHost != req.Settings.BlindHost
+		ctx.Settings = req.Settings
+
+		if blindHostChanged {
+			applyBlindHost(ctx)
+		}
+
+		ctx.BroadcastResp(WrapResponse(
+			RESP_UPDATE_SETTINGS,
+			UpdateSettingsResponse{
+				Settings: ctx.Settings,
+			},
+		))
+
+		return nil
+	}
+
 	if req := TryUnwrapStartGameRequest(req); req != nil {
 		adminPlayer := ctx.GetAdmin()
 		if adminPlayer == nil {
@@ -132,14 +325,8 @@ func (wsh *waitStageHandler) OnHandle(ctx *GameContext, req RequestWrapper) erro
 			return errors.New("无法开始游戏：只有管理员可以开始游戏")
 		}
 
-		// 检查词库是否已设置（必须至少包含两个词）
-		if len(ctx.WordList) < 2 || ctx.WordList[0] == "" || ctx.WordList[1] == "" {
-			return errors.New("无法开始游戏：管理员必须先设置正常词和卧底词")
-		}
-
-		// 检查玩家数量（按存活计数，排除管理员/观察者）
-		if ctx.CountAlive() < 8 {
-			return errors.New("无法开始游戏：玩家数量不足 8 人")
+		if err := checkCanStart(ctx); err != nil {
+			return fmt.Errorf("无法开始游戏：%w", err)
 		}
 
 		// 切换到准备阶段
@@ -160,6 +347,18 @@ func assignRolesAndWords(ctx *GameContext) {
 		spyWord string
 	)
 
+	// 选择了内置词库时由服务端抽取没玩过的词对
+	if ctx.WordFilter != nil {
+		pair, err := ctx.WordBank.Pick(*ctx.WordFilter, ctx.PlayedPairs)
+		if err != nil {
+			zap.L().Error("从词库抽取词对失败，无法分配角色", zap.String("roomID", ctx.RoomID), zap.Error(err))
+			// 这种情况不应该发生，因为 StartGame 已经验证过
+			return
+		}
+
+		ctx.WordList = []string{pair.Civilian, pair.Spy}
+	}
+
 	// 使用管理员设置的确定性词语
 	if len(ctx.WordList) < 2 {
 		zap.L().Error("词库未正确设置，无法分配角色", zap.String("roomID", ctx.RoomID))
@@ -171,7 +370,10 @@ func assignRolesAndWords(ctx *GameContext) {
 	answer = ctx.WordList[0]
 	spyWord = ctx.WordList[1]
 
-	// 抽选一个白板，一个卧底，其次为普通玩家
+	// 记录已玩过的词对，之后从词库抽词时跳过
+	ctx.PlayedPairs[wordbank.PairKey(answer, spyWord)] = true
+
+	// 按房间设置抽选白板和卧底，其次为普通玩家
 	slicedPlayers := make([]*Player, 0, len(ctx.Players))
 	for _, p := range ctx.Players {
 		if p.Role == ROLE_UNSET {
@@ -179,47 +381,178 @@ func assignRolesAndWords(ctx *GameContext) {
 		}
 	}
 
-	if len(slicedPlayers) < 2 {
+	blankCount := ctx.Settings.BlankCount
+	spyCount := ctx.Settings.SpyCount
+
+	if len(slicedPlayers) <= blankCount+spyCount {
 		zap.L().Error("参与分配的玩家不足，无法分配角色", zap.String("roomID", ctx.RoomID))
 		return
 	}
 
-	blankIndex := rand.IntN(len(slicedPlayers))
-	blankPlayer := slicedPlayers[blankIndex]
+	// 随机打乱后，前 blankCount 个为白板，随后 spyCount 个为卧底
+	rand.Shuffle(len(slicedPlayers), func(i, j int) {
+		slicedPlayers[i], slicedPlayers[j] = slicedPlayers[j], slicedPlayers[i]
+	})
 
-	tempPlayers := append(
-		slicedPlayers[:blankIndex],
-		slicedPlayers[blankIndex+1:]...,
-	)
+	// 最后分配角色和词语
+	ctx.AnswerWord = answer
+	ctx.SpyWord = spyWord
+
+	for i, p := range slicedPlayers {
+		switch {
+		case i < blankCount:
+			p.Role = ROLE_BLANK
+			p.Word = ""
+		case i < blankCount+spyCount:
+			p.Role = ROLE_SPY
+			p.Word = spyWord
+		default:
+			p.Role = ROLE_NORMAL
+			p.Word = answer
+		}
+	}
+}
+
+// applyBlindHost 在房间创建或切换盲主持模式时调用：让管理员按当前模式入座；
+// 开启时清除手动设置的词语，未选择筛选条件时从整个词库抽取
+func applyBlindHost(ctx *GameContext) {
+	if admin := ctx.GetAdmin(); admin != nil {
+		switch {
+		case ctx.Settings.BlindHost && admin.Role == ROLE_ADMIN:
+			seatAdmin(ctx, admin)
+		case !ctx.Settings.BlindHost && (admin.Role == ROLE_UNSET || admin.Role == ROLE_OBSERVER):
+			admin.Ready = false
+			seatAdmin(ctx, admin)
+		}
+	}
 
-	if len(tempPlayers) == 0 {
-		zap.L().Error("剩余玩家为空，无法分配卧底", zap.String("roomID", ctx.RoomID))
+	if !ctx.Settings.BlindHost {
 		return
 	}
 
-	undercoverPlayerIndex := rand.IntN(len(tempPlayers))
-	undercoverPlayer := tempPlayers[undercoverPlayerIndex]
+	ctx.WordList = make([]string, 0)
+	if ctx.WordFilter == nil {
+		ctx.WordFilter = &wordbank.Filter{}
+	}
 
-	// 最后分配角色和词语
-	ctx.AnswerWord = answer
-	ctx.SpyWord = spyWord
+	// 已选择的房间词库由房主上传，开启盲主持后退回内置词库
+	if ctx.DeckID != "" && ctx.Decks != nil {
+		if deck, err := ctx.Decks.Get(ctx.DeckID, ctx.RoomID); err != nil || deck.RoomID != "" {
+			ctx.WordBank = nil
+			ctx.DeckID = ""
+			ctx.WordFilter = &wordbank.Filter{}
 
-	blankPlayer.Role = ROLE_BLANK
-	blankPlayer.Word = ""
+			if builtin, err := ctx.Decks.Get(wordbank.BUILTIN_DECK_ID, ""); err == nil {
+				ctx.WordBank = builtin.Bank
+				ctx.DeckID = builtin.ID
+			}
+		}
+	}
+}
 
-	undercoverPlayer.Role = ROLE_SPY
-	undercoverPlayer.Word = spyWord
+// seatAdmin 让等待阶段的管理员入座：普通模式下占用管理员席位；盲主持模式下作为普通玩家参与游戏，满员时观战
+func seatAdmin(ctx *GameContext, player *Player) {
+	switch {
+	case !ctx.Settings.BlindHost:
+		player.Role = ROLE_ADMIN
+	case player.Role == ROLE_UNSET:
+	case ctx.CountAlive() < ctx.Settings.MaxPlayers:
+		player.Role = ROLE_UNSET
+	default:
+		player.Role = ROLE_OBSERVER
+	}
+}
 
-	// 为剩余的玩家分配普通角色
-	for _, p := range slicedPlayers {
-		if p.Role == ROLE_UNSET {
-			p.Role = ROLE_NORMAL
-			p.Word = answer
+// checkCanStart 检查词语和人数是否满足开始游戏的条件
+func checkCanStart(ctx *GameContext) error {
+	if ctx.WordFilter != nil {
+		// 使用内置词库时确认仍有没玩过的词对
+		if _, err := ctx.WordBank.Pick(*ctx.WordFilter, ctx.PlayedPairs); err != nil {
+			return err
+		}
+	} else if len(ctx.WordList) < 2 || ctx.WordList[0] == "" || ctx.WordList[1] == "" {
+		// 检查词库是否已设置（必须至少包含两个词）
+		return errors.New("管理员必须先设置正常词和卧底词，或通过 PickWords 选择内置词库")
+	}
+
+	// 检查玩家数量（按存活计数，排除管理员/观察者）
+	if ctx.CountAlive() < ctx.Settings.MinPlayers {
+		return fmt.Errorf("玩家数量不足 %d 人", ctx.Settings.MinPlayers)
+	}
+
+	return nil
+}
+
+// autoStartReady 判断是否满足自动开始的条件：在线的待分配玩家全部准备、人数足够，且已设置词语
+func autoStartReady(ctx *GameContext) bool {
+	if ctx.Settings.AutoStartSeconds <= 0 || checkCanStart(ctx) != nil {
+		return false
+	}
+
+	ready := 0
+	for _, p := range ctx.Players {
+		if p.Role != ROLE_UNSET || p.RespCh == nil {
+			continue
+		}
+
+		if !p.Ready {
+			return false
 		}
+		ready++
+	}
+
+	return ready >= ctx.Settings.MinPlayers
+}
+
+// refreshAutoStart 在等待阶段的每次事件处理后调用：条件满足时开始倒计时，条件不再满足时取消
+func refreshAutoStart(ctx *GameContext) {
+	if ctx.GameStage != STAGE_WAITING {
+		return
+	}
+
+	ready := autoStartReady(ctx)
+
+	switch {
+	case ready && !ctx.AutoStartPending:
+		ctx.AutoStartPending = true
+		ctx.SetTimeout(ctx.Settings.AutoStart())
+
+		zap.L().Info(
+			"玩家已全部准备，开始自动开始倒计时",
+			zap.String("room_id", ctx.RoomID),
+			zap.Int("seconds", ctx.Settings.AutoStartSeconds),
+		)
+
+		ctx.BroadcastResp(WrapResponse(
+			RESP_AUTO_START,
+			AutoStartNotification{
+				Started: true,
+				Seconds: ctx.Settings.AutoStartSeconds,
+			},
+		))
+
+	case !ready && ctx.AutoStartPending:
+		ctx.AutoStartPending = false
+		ctx.ClearTimeout()
+
+		zap.L().Info("自动开始条件不再满足，取消倒计时", zap.String("room_id", ctx.RoomID))
+
+		ctx.BroadcastResp(WrapResponse(
+			RESP_AUTO_START,
+			AutoStartNotification{
+				Started: false,
+			},
+		))
 	}
 }
 
 func (wsh *waitStageHandler) OnExit(ctx *GameContext) {
+	ctx.AutoStartPending = false
+	ctx.ClearTimeout()
+
+	for _, p := range ctx.Players {
+		p.Ready = false
+	}
 }
 
 func (wsh *waitStageHandler) SetOnSwitch(onSwitch func(string)) {
@@ -253,22 +586,24 @@ func (psh *prepStageHandler) OnEnter(ctx *GameContext) {
 	for _, p := range ctx.Players {
 		var resp ResponseWrapper
 		if p.Role != ROLE_ADMIN && !isObserverLike(p.Role) {
-			// 参与者：发送完整的角色和词语
+			// 参与者：发送角色和词语，不知身份模式下按房间设置隐藏角色
 			resp = WrapResponse(
 				RESP_START_GAME,
 				StartGameResponse{
-					AssignedRole: p.Role,
+					AssignedRole: toPrivateRole(ctx.Settings, p.Role),
 					AssignedWord: p.Word,
 				},
 			)
 		} else {
 			// 管理员/观察者：role 和 word 留空
 			if p.Role == ROLE_ADMIN {
-				// 管理员需要拿到所有玩家信息用于单播显示
+				// 管理员需要拿到所有玩家信息用于单播显示，角色同样按不知身份模式隐藏
 				players := make([]Player, 0, len(ctx.Players))
 				for _, gp := range ctx.Players {
 					// 复制值（会复制 RespCh 但该字段 json:"-"，不会被序列化）
-					players = append(players, *gp)
+					player := *gp
+					player.Role = toPrivateRole(ctx.Settings, gp.Role)
+					players = append(players, player)
 				}
 
 				resp = WrapResponse(
@@ -293,29 +628,17 @@ func (psh *prepStageHandler) OnEnter(ctx *GameContext) {
 		ctx.UnicastResp(p.ID, resp)
 	}
 
-	// 30 秒后自动切换到发言阶段
-	ctx.SetTimeout(30 * time.Second)
+	// 启动全局游戏时钟，到期后直接结算
+	ctx.StartGameClock(ctx.Settings.Timing.GameLimit())
+
+	// 准备时间结束后自动切换到发言阶段
+	ctx.SetTimeout(ctx.Settings.Timing.Prepare())
 }
 
 func (psh *prepStageHandler) OnHandle(ctx *GameContext, req RequestWrapper) error {
 	// 允许在任何阶段接受 JoinGame 请求（作为观察者或重连）
 	if jreq := TryUnwrapJoinGameRequest(req); jreq != nil {
-		playerID := jreq.PlayerID
-		if playerID == "" {
-			playerID = GenID()[len(GenID())-8:]
-		}
-
-		player := Player{
-			ID:     playerID,
-			Name:   jreq.JoinerName,
-			RespCh: jreq.RespCh,
-		}
-
-		if jreq.Observer {
-			player.Role = ROLE_OBSERVER
-		}
-
-		onPlayerJoin(ctx, player)
+		onJoinRequest(ctx, jreq)
 		return nil
 	}
 	// 处理超时请求
@@ -329,7 +652,7 @@ func (psh *prepStageHandler) OnHandle(ctx *GameContext, req RequestWrapper) erro
 
 	// 处理退出请求
 	if req := TryUnwrapExitGameRequest(req); req != nil {
-		onPlayerExit(ctx, req.PlayerID, req.RespCh)
+		onPlayerExit(ctx, req)
 		return nil
 	}
 
@@ -359,14 +682,23 @@ func (ssh *speakStageHandler) Stage() string {
 }
 
 func (ssh *speakStageHandler) OnEnter(ctx *GameContext) {
-	// 初始化发言顺序（随机打乱存活玩家）
+	// 初始化发言顺序（随机打乱存活玩家，加时赛中只有平票玩家发言）
 	alivePlayers := ctx.GetAlivePlayers()
 	ctx.SpeakingOrder = make([]string, 0, len(alivePlayers))
 
 	for _, p := range alivePlayers {
+		if ctx.InRunoff() && !ctx.IsRunoffCandidate(p.ID) {
+			continue
+		}
 		ctx.SpeakingOrder = append(ctx.SpeakingOrder, p.ID)
 	}
 
+	if len(ctx.SpeakingOrder) == 0 {
+		// 平票玩家均已离开，直接进入投票
+		ssh.onSwitch(STAGE_VOTING)
+		return
+	}
+
 	// 随机打乱顺序
 	rand.Shuffle(len(ctx.SpeakingOrder), func(i, j int) {
 		ctx.SpeakingOrder[i], ctx.SpeakingOrder[j] = ctx.SpeakingOrder[j], ctx.SpeakingOrder[i]
@@ -374,7 +706,8 @@ func (ssh *speakStageHandler) OnEnter(ctx *GameContext) {
 
 	// 确保白板（ROLE_BLANK）至少在第4位开始发言（1-based 第4位 -> 0-based index 3）。
 	// 如果玩家数量不足以放到第4位，则尽量放到最后一位。
-	if len(ctx.SpeakingOrder) > 1 {
+	// 加时赛中平票玩家依次补充发言，不调整白板位置
+	if len(ctx.SpeakingOrder) > 1 && !ctx.InRunoff() {
 		// 查找白板的当前索引
 		blankIdx := -1
 		for i, id := range ctx.SpeakingOrder {
@@ -413,74 +746,23 @@ func (ssh *speakStageHandler) OnEnter(ctx *GameContext) {
 	ctx.CurrentSpeakerIdx = 0
 
 	// 广播进入发言阶段
-	currentPlayer := ctx.Players[ctx.SpeakingOrder[0]]
-	stateNotif := WrapResponse(
-		RESP_GAME_STATE,
-		GameStateNotification{
-			Stage:           STAGE_SPEAKING,
-			CurrentTurnID:   currentPlayer.ID,
-			CurrentTurnName: currentPlayer.Name,
-			Round:           ctx.Round,
-		},
-	)
+	broadcastSpeakingState(ctx, ctx.Players[ctx.SpeakingOrder[0]])
 
-	ctx.BroadcastResp(stateNotif)
-
-	// 设置 40 秒超时
-	ctx.SetTimeout(40 * time.Second)
+	// 设置首位发言者超时
+	ctx.SetTimeout(ctx.Settings.Timing.FirstSpeak())
 }
 
 func (ssh *speakStageHandler) OnHandle(ctx *GameContext, req RequestWrapper) error {
 	// 允许在任何阶段接受 JoinGame 请求（作为观察者或重连）
 	if jreq := TryUnwrapJoinGameRequest(req); jreq != nil {
-		playerID := jreq.PlayerID
-		if playerID == "" {
-			playerID = GenID()[len(GenID())-8:]
-		}
-
-		player := Player{
-			ID:     playerID,
-			Name:   jreq.JoinerName,
-			RespCh: jreq.RespCh,
-		}
-
-		if jreq.Observer {
-			player.Role = ROLE_OBSERVER
-		}
-
-		onPlayerJoin(ctx, player)
+		onJoinRequest(ctx, jreq)
 		return nil
 	}
 	// 处理超时请求
 	if req := TryUnwrapTimeoutRequest(req); req != nil {
 		if req.Stage == STAGE_SPEAKING {
 			// 当前发言者超时，移动到下一个发言者
-			ctx.CurrentSpeakerIdx++
-
-			// 检查是否所有人都已发言
-			if ctx.CurrentSpeakerIdx >= len(ctx.SpeakingOrder) {
-				// 所有人都已发言，切换到投票阶段
-				ssh.onSwitch(STAGE_VOTING)
-				return nil
-			}
-
-			// 通知下一位玩家发言
-			nextPlayer := ctx.Players[ctx.SpeakingOrder[ctx.CurrentSpeakerIdx]]
-			stateNotif := WrapResponse(
-				RESP_GAME_STATE,
-				GameStateNotification{
-					Stage:           STAGE_SPEAKING,
-					CurrentTurnID:   nextPlayer.ID,
-					CurrentTurnName: nextPlayer.Name,
-					Round:           ctx.Round,
-				},
-			)
-
-			ctx.BroadcastResp(stateNotif)
-
-			// 重新设置 20 秒超时
-			ctx.SetTimeout(20 * time.Second)
-
+			advanceSpeaker(ctx)
 			return nil
 		}
 	}
@@ -494,12 +776,61 @@ func (ssh *speakStageHandler) OnHandle(ctx *GameContext, req RequestWrapper) err
 
 		// 广播发言内容
 		speaker := ctx.Players[req.ReqPlayerID]
+		if speaker.Muted {
+			return errors.New("你已被管理员禁言")
+		}
+
+		message := req.Message
+		masked := false
+
+		// 发言中出现词语时按房间策略处理。拒绝和遮挡会把匹配结果反馈给发言者，
+		// 只检查自己的词语，避免卧底或白板借此反复试探平民词；淘汰时才检查平民词和卧底词
+		words := []string{speaker.Word}
+		if ctx.Settings.LeakPolicy == LEAK_POLICY_ELIMINATE {
+			words = append(words, ctx.AnswerWord, ctx.SpyWord)
+		}
+
+		if leaked := wordmatch.Find(message, words...); len(leaked) > 0 {
+			zap.L().Info(
+				"发言阶段：发言泄露词语",
+				zap.String("roomID", ctx.RoomID),
+				zap.String("speaker_id", speaker.ID),
+				zap.String("policy", ctx.Settings.LeakPolicy),
+			)
+
+			leakNotif := WrapResponse(
+				RESP_WORD_LEAK,
+				WordLeakNotification{
+					SpeakerID:   speaker.ID,
+					SpeakerName: speaker.Name,
+					Policy:      ctx.Settings.LeakPolicy,
+				},
+			)
+
+			switch ctx.Settings.LeakPolicy {
+			case LEAK_POLICY_MASK:
+				message = wordmatch.Mask(message, leaked...)
+				masked = true
+			case LEAK_POLICY_ELIMINATE:
+				ctx.BroadcastResp(leakNotif)
+				eliminatePlayer(ctx, speaker.ID)
+				// 与参与者离开相同：检查胜负，并从发言顺序中移除，轮到下一位
+				onParticipantDeparted(ctx, speaker.ID, FINISH_REASON_WORD_LEAK)
+				return nil
+			default:
+				// 不广播该条发言，发言者在剩余时间内重新描述
+				ctx.UnicastResp(speaker.ID, leakNotif)
+				return errors.New("发言中包含词语")
+			}
+		}
+
 		descResp := WrapResponse(
 			RESP_DESCRIBE,
 			DescribeResponse{
 				SpeakerID:   speaker.ID,
 				SpeakerName: speaker.Name,
-				Message:     req.Message,
+				Message:     message,
+				Masked:      masked,
 			},
 		)
 
@@ -516,34 +847,58 @@ func (ssh *speakStageHandler) OnHandle(ctx *GameContext, req RequestWrapper) err
 		}
 
 		// 通知下一位玩家发言
-		nextPlayer := ctx.Players[ctx.SpeakingOrder[ctx.CurrentSpeakerIdx]]
-		stateNotif := WrapResponse(
-			RESP_GAME_STATE,
-			GameStateNotification{
-				Stage:           STAGE_SPEAKING,
-				CurrentTurnID:   nextPlayer.ID,
-				CurrentTurnName: nextPlayer.Name,
-				Round:           ctx.Round,
-			},
-		)
-
-		ctx.BroadcastResp(stateNotif)
+		broadcastSpeakingState(ctx, ctx.Players[ctx.SpeakingOrder[ctx.CurrentSpeakerIdx]])
 
-		// 重新设置 20 秒超时
-		ctx.SetTimeout(20 * time.Second)
+		// 重新设置发言超时
+		ctx.SetTimeout(ctx.Settings.Timing.Speak())
 
 		return nil
 	}
 
 	// 处理退出请求
 	if req := TryUnwrapExitGameRequest(req); req != nil {
-		onPlayerExit(ctx, req.PlayerID, req.RespCh)
+		onPlayerExit(ctx, req)
 		return nil
 	}
 
 	return errors.New("发言阶段只接受 Describe 和 ExitGame 请求")
 }
 
+// broadcastSpeakingState 广播当前发言者，加时赛中同时携带平票玩家列表
+func broadcastSpeakingState(ctx *GameContext, speaker *Player) {
+	stateNotif := WrapResponse(
+		RESP_GAME_STATE,
+		GameStateNotification{
+			Stage:            STAGE_SPEAKING,
+			CurrentTurnID:    speaker.ID,
+			CurrentTurnName:  speaker.Name,
+			Round:            ctx.Round,
+			Runoff:           ctx.InRunoff(),
+			RunoffCandidates: ctx.RunoffRefs(),
+		},
+	)
+
+	ctx.BroadcastResp(stateNotif)
+}
+
+// advanceSpeaker 跳过当前发言者：轮到下一位，所有人都已发言时进入投票阶段
+func advanceSpeaker(ctx *GameContext) {
+	ctx.CurrentSpeakerIdx++
+
+	// 检查是否所有人都已发言
+	if ctx.CurrentSpeakerIdx >= len(ctx.SpeakingOrder) {
+		// 直接修改阶段，状态机会在本次事件处理后检测到变化并切换
+		ctx.GameStage = STAGE_VOTING
+		return
+	}
+
+	// 通知下一位玩家发言
+	broadcastSpeakingState(ctx, ctx.Players[ctx.SpeakingOrder[ctx.CurrentSpeakerIdx]])
+
+	// 重新设置发言超时
+	ctx.SetTimeout(ctx.Settings.Timing.Speak())
+}
+
 func (ssh *speakStageHandler) OnExit(ctx *GameContext) {
 	ctx.ClearTimeout()
 }
@@ -573,36 +928,23 @@ func (vsh *voteStageHandler) OnEnter(ctx *GameContext) {
 	stateNotif := WrapResponse(
 		RESP_GAME_STATE,
 		GameStateNotification{
-			Stage: STAGE_VOTING,
-			Round: ctx.Round,
+			Stage:            STAGE_VOTING,
+			Round:            ctx.Round,
+			Runoff:           ctx.InRunoff(),
+			RunoffCandidates: ctx.RunoffRefs(),
 		},
 	)
 
 	ctx.BroadcastResp(stateNotif)
 
-	// 设置 30 秒超时
-	ctx.SetTimeout(30 * time.Second)
+	// 设置投票超时
+	ctx.SetTimeout(ctx.Settings.Timing.Vote())
 }
 
 func (vsh *voteStageHandler) OnHandle(ctx *GameContext, req RequestWrapper) error {
 	// 允许在任何阶段接受 JoinGame 请求（作为观察者或重连）
 	if jreq := TryUnwrapJoinGameRequest(req); jreq != nil {
-		playerID := jreq.PlayerID
-		if playerID == "" {
-			playerID = GenID()[len(GenID())-8:]
-		}
-
-		player := Player{
-			ID:     playerID,
-			Name:   jreq.JoinerName,
-			RespCh: jreq.RespCh,
-		}
-
-		if jreq.Observer {
-			player.Role = ROLE_OBSERVER
-		}
-
-		onPlayerJoin(ctx, player)
+		onJoinRequest(ctx, jreq)
 		return nil
 	}
 	// 处理超时请求
@@ -625,38 +967,71 @@ func (vsh *voteStageHandler) OnHandle(ctx *GameContext, req RequestWrapper) erro
 			return errors.New("观察者和管理员不能投票")
 		}
 
-		// 验证被投票者是否存活
-		target, ok := ctx.Players[req.TargetID]
-		if !ok {
-			return errors.New("被投票者不存在")
+		// 加时赛中平票玩家不投票
+		if ctx.InRunoff() && ctx.IsRunoffCandidate(voter.ID) {
+			return errors.New("加时赛中平票玩家不能投票")
 		}
 
-		if isObserverLike(target.Role) || target.Role == ROLE_ADMIN {
-			return errors.New("不能投票给观察者或管理员")
+		voteInfo := VoteResponse{
+			VoterID:   voter.ID,
+			VoterName: voter.Name,
+			Abstain:   req.Abstain,
 		}
 
-		// 记录投票
-		if _, alreadyVoted := ctx.Votes[req.VoterID]; alreadyVoted {
-			return errors.New("你已投票，不能重复投票")
-		}
-		ctx.Votes[req.VoterID] = req.TargetID
-
-		// 广播投票信息
-		voteResp := WrapResponse(
-			RESP_VOTE,
-			VoteResponse{
-				VoterID:    voter.ID,
-				VoterName:  voter.Name,
-				TargetID:   target.ID,
-				TargetName: target.Name,
-			},
-		)
+		// 弃权票不需要被投票者
+		targetID := VOTE_ABSTAIN
+		if !req.Abstain {
+			// 验证被投票者是否存活
+			target, ok := ctx.Players[req.TargetID]
+			if !ok {
+				return errors.New("被投票者不存在")
+			}
 
-		ctx.BroadcastResp(voteResp)
+			if isObserverLike(target.Role) || target.Role == ROLE_ADMIN {
+				return errors.New("不能投票给观察者或管理员")
+			}
+
+			// 加时赛中只能在平票玩家中选择
+			if ctx.InRunoff() && !ctx.IsRunoffCandidate(target.ID) {
+				return errors.New("加时赛中只能投票给平票玩家")
+			}
+
+			targetID = target.ID
+			voteInfo.TargetID = target.ID
+			voteInfo.TargetName = target.Name
+		}
+
+		// 记录投票（允许改票时，截止前可覆盖之前的投票）
+		if _, alreadyVoted := ctx.Votes[req.VoterID]; alreadyVoted {
+			if !ctx.Settings.AllowVoteChange {
+				return errors.New("你已投票，不能重复投票")
+			}
+			voteInfo.Changed = true
+		}
+		ctx.Votes[req.VoterID] = targetID
+
+		eligibleVoters := countEligibleVoters(ctx, ctx.RunoffCandidates)
+
+		if ctx.Settings.SecretBallot {
+			// 不记名投票：只向投票者本人确认，其余玩家只能看到投票进度
+			ctx.UnicastResp(voter.ID, WrapResponse(RESP_VOTE, voteInfo))
+
+			ctx.BroadcastResp(WrapResponse(
+				RESP_VOTE_PROGRESS,
+				VoteProgressNotification{
+					Voted: len(ctx.Votes),
+					Total: eligibleVoters,
+				},
+			))
+		} else {
+			// 广播投票信息
+			voteResp := WrapResponse(RESP_VOTE, voteInfo)
+
+			ctx.BroadcastResp(voteResp)
+		}
 
-		// 检查是否所有存活玩家都已投票
-		aliveCount := ctx.CountAlive()
-		if len(ctx.Votes) >= aliveCount {
+		// 允许改票时投票持续到截止时间，否则所有有投票权的玩家投完即进入判定阶段
+		if !ctx.Settings.AllowVoteChange && len(ctx.Votes) >= eligibleVoters {
 			// 所有人都已投票，切换到判定阶段
 			vsh.onSwitch(STAGE_JUDGING)
 		}
@@ -666,13 +1041,26 @@ func (vsh *voteStageHandler) OnHandle(ctx *GameContext, req RequestWrapper) erro
 
 	// 处理退出请求
 	if req := TryUnwrapExitGameRequest(req); req != nil {
-		onPlayerExit(ctx, req.PlayerID, req.RespCh)
+		onPlayerExit(ctx, req)
 		return nil
 	}
 
 	return errors.New("投票阶段只接受 Vote 和 ExitGame 请求")
 }
 
+// countEligibleVoters 统计有投票权的存活玩家数，平票玩家在加时赛中没有投票权
+func countEligibleVoters(ctx *GameContext, runoffCandidates []string) int {
+	count := 0
+	for _, p := range ctx.GetAlivePlayers() {
+		if slices.Contains(runoffCandidates, p.ID) {
+			continue
+		}
+		count++
+	}
+
+	return count
+}
+
 func (vsh *voteStageHandler) OnExit(ctx *GameContext) {
 	ctx.ClearTimeout()
 }
@@ -695,18 +1083,332 @@ func (jsh *judgeStageHandler) Stage() string {
 }
 
 func (jsh *judgeStageHandler) OnEnter(ctx *GameContext) {
-	// 计票
+	// 不记名投票在判定时一次性公开完整的投票表
+	if ctx.Settings.SecretBallot {
+		ctx.BroadcastResp(WrapResponse(
+			RESP_VOTE_REVEAL,
+			VoteRevealNotification{
+				Round:   ctx.Round,
+				Runoff:  ctx.InRunoff(),
+				Ballots: buildBallots(ctx),
+			},
+		))
+	}
+
+	// 计票，找出得票最多的玩家（加时赛中只统计平票玩家）
+	voteCount := countVotes(ctx)
+	candidates, maxVotes := tallyTopCandidates(ctx, voteCount)
+
+	// 在淘汰改变玩家身份之前记录本轮得票
+	summary := newRoundSummary(ctx, voteCount)
+
+	if len(candidates) == 0 {
+		// 理论上不可能发生（除非没有存活玩家，但那样游戏早已结束）
+		zap.L().Warn("裁判阶段：无候选玩家", zap.String("roomID", ctx.RoomID))
+		ctx.RunoffCandidates = nil
+		jsh.onSwitch(STAGE_FINISHED)
+		return
+	}
+
+	var eliminatedIDs []string
+	noElimReason := NO_ELIMINATION_TIE
+
+	switch {
+	case maxVotes < ctx.Settings.EffectiveQuorum():
+		// 最高票未达到淘汰所需票数（弃权和未投票均不计票），本轮无人出局
+		noElimReason = NO_ELIMINATION_QUORUM
+	case len(candidates) == 1:
+		eliminatedIDs = candidates
+	case !ctx.InRunoff() && countEligibleVoters(ctx, candidates) > 0:
+		// 首次平票：进入加时赛，平票玩家再次发言后由其余玩家投票
+		summary.TiedCandidates = ctx.playerRefs(candidates)
+		summary.TieBreak = TIE_BREAK_RUNOFF
+		ctx.BroadcastResp(WrapResponse(RESP_ROUND_SUMMARY, summary))
+
+		startRunoff(ctx, candidates)
+		return
+	default:
+		// 加时赛后仍然平票（或没有玩家可以参与加时赛投票），按房间策略处理
+		eliminatedIDs = resolveTie(ctx, candidates)
+
+		summary.TiedCandidates = ctx.playerRefs(candidates)
+		summary.TieBreak = ctx.Settings.TiePolicy
+		// 汇总报告实际的处理结果：all 策略在全部存活玩家平票时无人出局
+		if len(eliminatedIDs) == 0 {
+			summary.TieBreak = TIE_POLICY_NONE
+		}
+	}
+
+	ctx.RunoffCandidates = nil
+
+	if len(eliminatedIDs) == 0 {
+		zap.L().Info(
+			"判定阶段：本轮无人出局",
+			zap.String("roomID", ctx.RoomID),
+			zap.String("reason", noElimReason),
+			zap.Strings("candidates", candidates),
+			zap.Int("max_votes", maxVotes),
+		)
+
+		summary.NoEliminationReason = noElimReason
+
+		notif := NoEliminationNotification{
+			Reason:   noElimReason,
+			MaxVotes: maxVotes,
+			Quorum:   ctx.Settings.EffectiveQuorum(),
+			Round:    ctx.Round,
+		}
+		if noElimReason == NO_ELIMINATION_TIE {
+			notif.TiedCandidates = ctx.playerRefs(candidates)
+		}
+
+		ctx.BroadcastResp(WrapResponse(RESP_NO_ELIMINATION, notif))
+	}
+
+	for _, eliminatedID := range eliminatedIDs {
+		eliminatePlayer(ctx, eliminatedID)
+	}
+
+	// 广播本轮投票汇总，便于玩家复盘
+	summary.Eliminated = ctx.playerRefs(eliminatedIDs)
+	ctx.BroadcastResp(WrapResponse(RESP_ROUND_SUMMARY, summary))
+
+	// 最终猜词：出局的卧底和白板依次获得一次猜平民词的机会，全部猜错后再检查胜负
+	if ctx.Settings.FinalGuess {
+		for _, eliminatedID := range eliminatedIDs {
+			if p := ctx.Players[eliminatedID]; p != nil && (p.Role == ROLE_OB_SPY || p.Role == ROLE_OB_BLANK) {
+				ctx.FinalGuessers = append(ctx.FinalGuessers, eliminatedID)
+			}
+		}
+
+		if promptFinalGuess(ctx) {
+			return
+		}
+	}
+
+	jsh.concludeRound(ctx)
+}
+
+// concludeRound 判定结束后检查胜负和轮数上限，未结束时等待判定展示时间后进入下一轮
+func (jsh *judgeStageHandler) concludeRound(ctx *GameContext) {
+	// 检查胜利条件
+	if isGameDecided(ctx) {
+		jsh.onSwitch(STAGE_FINISHED)
+		return
+	}
+
+	// 未分出胜负，继续下一轮
+	ctx.Round++
+
+	// 轮数上限：先检查胜负再执行轮数限制
+	if ctx.Round > ctx.Settings.MaxRounds {
+		jsh.onSwitch(STAGE_FINISHED)
+		return
+	}
+
+	// 判定展示时间结束后进入下一轮 Speaking
+	ctx.SetTimeout(ctx.Settings.Timing.Judge())
+}
+
+// promptFinalGuess 向队首仍在房间内的猜词者单播 GuessWord 提示并开始计时，没有猜词者时返回 false
+func promptFinalGuess(ctx *GameContext) bool {
+	for len(ctx.FinalGuessers) > 0 {
+		guesser, ok := ctx.Players[ctx.FinalGuessers[0]]
+		if !ok {
+			// 猜词者已被踢出房间
+			ctx.FinalGuessers = ctx.FinalGuessers[1:]
+			continue
+		}
+
+		ctx.UnicastResp(guesser.ID, WrapResponse(
+			RESP_GUESS_WORD,
+			GuessWordPrompt{
+				PlayerID:         guesser.ID,
+				PlayerName:       guesser.Name,
+				RemainingSeconds: ctx.Settings.Timing.GuessSeconds,
+			},
+		))
+
+		ctx.SetTimeout(ctx.Settings.Timing.Guess())
+
+		return true
+	}
+
+	return false
+}
+
+// resolveFinalGuess 处理队首猜词者的猜测（超时视为放弃）：猜中则卧底方获胜，否则轮到下一位猜词者或继续判定
+func (jsh *judgeStageHandler) resolveFinalGuess(ctx *GameContext, guess string, timedOut bool) {
+	guesserID := ctx.FinalGuessers[0]
+	ctx.FinalGuessers = ctx.FinalGuessers[1:]
+	ctx.ClearTimeout()
+
+	correct := !timedOut && wordmatch.Equal(guess, ctx.AnswerWord)
+
+	zap.L().Info(
+		"判定阶段：最终猜词",
+		zap.String("roomID", ctx.RoomID),
+		zap.String("guesser_id", guesserID),
+		zap.Bool("correct", correct),
+		zap.Bool("timed_out", timedOut),
+	)
+
+	if guesser, ok := ctx.Players[guesserID]; ok {
+		notif := GuessResultNotification{
+			PlayerID:   guesser.ID,
+			PlayerName: guesser.Name,
+			Correct:    correct,
+			TimedOut:   timedOut,
+		}
+		if correct {
+			notif.Guess = guess
+		}
+
+		ctx.BroadcastResp(WrapResponse(RESP_GUESS_RESULT, notif))
+	}
+
+	if correct {
+		ctx.FinalGuessers = nil
+		ctx.FinishReason = FINISH_REASON_FINAL_GUESS
+		jsh.onSwitch(STAGE_FINISHED)
+		return
+	}
+
+	if promptFinalGuess(ctx) {
+		return
+	}
+
+	jsh.concludeRound(ctx)
+}
+
+// buildBallots 按投票者名称排序生成投票表
+func buildBallots(ctx *GameContext) []VoteResponse {
+	ballots := make([]VoteResponse, 0, len(ctx.Votes))
+	for voterID, targetID := range ctx.Votes {
+		ballot := VoteResponse{
+			VoterID: voterID,
+			Abstain: targetID == VOTE_ABSTAIN,
+		}
+
+		if voter, ok := ctx.Players[voterID]; ok {
+			ballot.VoterName = voter.Name
+		}
+
+		if target, ok := ctx.Players[targetID]; ok {
+			ballot.TargetID = target.ID
+			ballot.TargetName = target.Name
+		}
+
+		ballots = append(ballots, ballot)
+	}
+
+	slices.SortFunc(ballots, func(a, b VoteResponse) int {
+		return strings.Compare(a.VoterName, b.VoterName)
+	})
+
+	return ballots
+}
+
+// countVotes 统计每位玩家的得票数，弃权票不计入
+func countVotes(ctx *GameContext) map[string]int {
 	voteCount := make(map[string]int)
 	for _, targetID := range ctx.Votes {
+		if targetID == VOTE_ABSTAIN {
+			continue
+		}
 		voteCount[targetID]++
 	}
 
-	// 找出得票最多的玩家（处理同票和0票情况）
+	return voteCount
+}
+
+// newRoundSummary 根据得票生成本轮汇总，平票和淘汰信息由调用方补充
+func newRoundSummary(ctx *GameContext, voteCount map[string]int) RoundSummary {
+	summary := RoundSummary{
+		Round:      ctx.Round,
+		Runoff:     ctx.InRunoff(),
+		Counts:     make([]CandidateCount, 0),
+		Quorum:     ctx.Settings.EffectiveQuorum(),
+		TieBreak:   TIE_BREAK_NONE,
+		Eliminated: make([]PlayerRef, 0),
+	}
+
+	for _, p := range ctx.GetAlivePlayers() {
+		if ctx.InRunoff() && !ctx.IsRunoffCandidate(p.ID) {
+			continue
+		}
+
+		summary.Counts = append(summary.Counts, CandidateCount{
+			ID:    p.ID,
+			Name:  p.Name,
+			Votes: voteCount[p.ID],
+		})
+	}
+
+	// 按得票从高到低排序，同票按名称排序
+	slices.SortFunc(summary.Counts, func(a, b CandidateCount) int {
+		if a.Votes != b.Votes {
+			return b.Votes - a.Votes
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	for _, targetID := range ctx.Votes {
+		if targetID == VOTE_ABSTAIN {
+			summary.Abstentions++
+		}
+	}
+
+	// 不记名投票的投票表已通过 VoteReveal 公开，汇总中不再重复
+	if !ctx.Settings.SecretBallot {
+		summary.Ballots = buildBallots(ctx)
+	}
+
+	return summary
+}
+
+// isGameDecided 检查胜负条件，判定阶段和玩家中途离开时共用
+func isGameDecided(ctx *GameContext) bool {
+	aliveCount := ctx.CountAlive()
+	spyAlive := ctx.IsSpyAlive()
+	blankAlive := ctx.IsBlankAlive()
+
+	zap.L().Info(
+		"检查胜负条件",
+		zap.String("roomID", ctx.RoomID),
+		zap.String("stage", ctx.GameStage),
+		zap.Int("alive_count", aliveCount),
+		zap.Bool("spy_alive", spyAlive),
+		zap.Bool("blank_alive", blankAlive),
+	)
+
+	// 平民方胜利：卧底和白板均已出局 -> 立即结束（优先判定）
+	if !spyAlive && !blankAlive {
+		zap.L().Info("平民胜利，切换 Finished", zap.String("roomID", ctx.RoomID))
+		return true
+	}
+
+	// 卧底/白板方胜利：存活人数 <= 阈值 且 卧底或白板尚在场
+	// （标准局中当已有 4 人被淘汰时，若卧底或白板仍在场，可立即判定其为胜利方）
+	if aliveCount <= ctx.Settings.SpyWinThreshold && (spyAlive || blankAlive) {
+		zap.L().Info("卧底/白板胜利，切换 Finished", zap.String("roomID", ctx.RoomID))
+		return true
+	}
+
+	return false
+}
+
+// tallyTopCandidates 返回得票最多的玩家（可能多人平票）及其票数，加时赛中只有平票玩家参与统计
+func tallyTopCandidates(ctx *GameContext, voteCount map[string]int) ([]string, int) {
 	candidates := make([]string, 0)
-	maxVotes := -1 // 初始为-1以确保0票也能被选中（如果有负票逻辑，这里要注意，不过一般没有）
+	maxVotes := -1 // 初始为-1以确保0票也能被选中
 
 	// 遍历所有存活玩家，确保0票玩家也在候选之列
 	for _, p := range ctx.GetAlivePlayers() {
+		if ctx.InRunoff() && !ctx.IsRunoffCandidate(p.ID) {
+			continue
+		}
+
 		votes := voteCount[p.ID]
 		if votes > maxVotes {
 			maxVotes = votes
@@ -716,18 +1418,50 @@ func (jsh *judgeStageHandler) OnEnter(ctx *GameContext) {
 		}
 	}
 
-	// 如果有多个平票玩家，随机选择一个
-	var eliminatedID string
-	if len(candidates) > 0 {
-		eliminatedID = candidates[rand.IntN(len(candidates))]
-	} else {
-		// 理论上不可能发生（除非没有存活玩家，但那样游戏早已结束）
-		zap.L().Warn("裁判阶段：无候选玩家", zap.String("roomID", ctx.RoomID))
-		jsh.onSwitch(STAGE_FINISHED)
-		return
+	return candidates, maxVotes
+}
+
+// startRunoff 记录平票玩家并广播加时赛，判定展示时间结束后平票玩家再次发言
+func startRunoff(ctx *GameContext, candidates []string) {
+	ctx.RunoffCandidates = candidates
+
+	zap.L().Info(
+		"判定阶段：平票，进入加时赛",
+		zap.String("roomID", ctx.RoomID),
+		zap.Strings("candidates", candidates),
+	)
+
+	ctx.BroadcastResp(WrapResponse(
+		RESP_GAME_STATE,
+		GameStateNotification{
+			Stage:            STAGE_JUDGING,
+			Round:            ctx.Round,
+			Runoff:           true,
+			RunoffCandidates: ctx.RunoffRefs(),
+		},
+	))
+
+	ctx.SetTimeout(ctx.Settings.Timing.Judge())
+}
+
+// resolveTie 按房间的平票处理策略决定淘汰哪些玩家，返回空表示无人出局
+func resolveTie(ctx *GameContext, candidates []string) []string {
+	switch ctx.Settings.TiePolicy {
+	case TIE_POLICY_NONE:
+		return nil
+	case TIE_POLICY_ALL:
+		// 平票玩家即全部存活玩家时，全部淘汰没有意义，视为无人出局
+		if len(candidates) >= ctx.CountAlive() {
+			return nil
+		}
+		return candidates
+	default:
+		return []string{candidates[rand.IntN(len(candidates))]}
 	}
+}
 
-	// 淘汰该玩家
+// eliminatePlayer 淘汰玩家并广播淘汰信息
+func eliminatePlayer(ctx *GameContext, eliminatedID string) {
 	eliminated := ctx.Players[eliminatedID]
 	if eliminated == nil {
 		zap.L().Error("裁判阶段：被淘汰玩家不存在", zap.String("roomID", ctx.RoomID), zap.String("eliminatedID", eliminatedID))
@@ -737,16 +1471,7 @@ func (jsh *judgeStageHandler) OnEnter(ctx *GameContext) {
 	eliminatedWord := eliminated.Word
 
 	// 将被淘汰玩家角色标记为内部 Ob*，以便 GameResult 还原原身份
-	switch eliminated.Role {
-	case ROLE_SPY:
-		eliminated.Role = ROLE_OB_SPY
-	case ROLE_BLANK:
-		eliminated.Role = ROLE_OB_BLANK
-	case ROLE_NORMAL:
-		eliminated.Role = ROLE_OB_NORMAL
-	default:
-		eliminated.Role = ROLE_OBSERVER
-	}
+	eliminated.Role = toEliminatedRole(eliminated.Role)
 
 	// 广播淘汰信息
 	elimNotif := WrapResponse(
@@ -759,80 +1484,44 @@ func (jsh *judgeStageHandler) OnEnter(ctx *GameContext) {
 	)
 
 	ctx.BroadcastResp(elimNotif)
-
-	// 检查胜利条件
-	aliveCount := ctx.CountAlive()
-	spyAlive := ctx.IsSpyAlive()
-	blankAlive := ctx.IsBlankAlive()
-
-	zap.L().Info(
-		"判定阶段：检查胜负条件",
-		zap.String("roomID", ctx.RoomID),
-		zap.Int("alive_count", aliveCount),
-		zap.Bool("spy_alive", spyAlive),
-		zap.Bool("blank_alive", blankAlive),
-	)
-
-	// 平民方胜利：卧底和白板均已出局 -> 立即结束（优先判定）
-	if !spyAlive && !blankAlive {
-		zap.L().Info("判定阶段：平民胜利，切换 Finished", zap.String("roomID", ctx.RoomID))
-		jsh.onSwitch(STAGE_FINISHED)
-		return
-	}
-
-	// 卧底/白板方胜利：存活人数 <= 4 且 卧底或白板尚在场
-	// （当已有 4 人被淘汰时，若卧底或白板仍在场，可立即判定其为胜利方）
-	if aliveCount <= 4 && (spyAlive || blankAlive) {
-		zap.L().Info("判定阶段：卧底/白板胜利，切换 Finished", zap.String("roomID", ctx.RoomID))
-		jsh.onSwitch(STAGE_FINISHED)
-		return
-	}
-
-	// 未分出胜负，继续下一轮
-	ctx.Round++
-
-	// 四轮上限：先检查胜负再执行轮数限制
-	if ctx.Round > 4 {
-		jsh.onSwitch(STAGE_FINISHED)
-		return
-	}
-
-	// 10 秒后进入下一轮 Speaking
-	ctx.SetTimeout(10 * time.Second)
 }
 
 func (jsh *judgeStageHandler) OnHandle(ctx *GameContext, req RequestWrapper) error {
 	// 允许在任何阶段接受 JoinGame 请求（作为观察者或重连）
 	if jreq := TryUnwrapJoinGameRequest(req); jreq != nil {
-		playerID := jreq.PlayerID
-		if playerID == "" {
-			playerID = GenID()[len(GenID())-8:]
-		}
-
-		player := Player{
-			ID:     playerID,
-			Name:   jreq.JoinerName,
-			RespCh: jreq.RespCh,
-		}
-
-		if jreq.Observer {
-			player.Role = ROLE_OBSERVER
-		}
-
-		onPlayerJoin(ctx, player)
+		onJoinRequest(ctx, jreq)
 		return nil
 	}
 	// 处理超时请求
 	if req := TryUnwrapTimeoutRequest(req); req != nil {
 		if req.Stage == STAGE_JUDGING {
+			if len(ctx.FinalGuessers) > 0 {
+				// 猜词超时，视为放弃
+				jsh.resolveFinalGuess(ctx, "", true)
+				return nil
+			}
+
 			// 超时，进入下一轮发言
 			jsh.onSwitch(STAGE_SPEAKING)
 			return nil
 		}
 	}
+	// 处理最终猜词
+	if req := TryUnwrapGuessWordRequest(req); req != nil {
+		if len(ctx.FinalGuessers) == 0 || ctx.FinalGuessers[0] != req.ReqPlayerID {
+			return errors.New("当前不是你的猜词机会")
+		}
+
+		if strings.TrimSpace(req.Guess) == "" {
+			return errors.New("猜测的词语不能为空")
+		}
+
+		jsh.resolveFinalGuess(ctx, req.Guess, false)
+		return nil
+	}
 	// 处理退出请求
 	if req := TryUnwrapExitGameRequest(req); req != nil {
-		onPlayerExit(ctx, req.PlayerID, req.RespCh)
+		onPlayerExit(ctx, req)
 		return nil
 	}
 	// 判定阶段不处理其他任何请求
@@ -841,12 +1530,20 @@ func (jsh *judgeStageHandler) OnHandle(ctx *GameContext, req RequestWrapper) err
 
 func (jsh *judgeStageHandler) OnExit(ctx *GameContext) {
 	ctx.ClearTimeout()
+	ctx.FinalGuessers = nil
 }
 
 func (jsh *judgeStageHandler) SetOnSwitch(onSwitch func(string)) {
 	jsh.onSwitch = onSwitch
 }
 
+const (
+	// 结束阶段等待管理员发起再来一局的时长，超时后关闭房间
+	REMATCH_LINGER = 5 * time.Minute
+	// 发起再来一局后，玩家可选择退出的窗口期
+	REMATCH_WINDOW = 15 * time.Second
+)
+
 // 结束阶段处理器
 type finishStageHandler struct {
 	onSwitch func(string)
@@ -861,14 +1558,34 @@ func (fsh *finishStageHandler) Stage() string {
 }
 
 func (fsh *finishStageHandler) OnEnter(ctx *GameContext) {
-	// 确定胜利方
+	// 暂停期间因玩家离开分出胜负时，先解除暂停
+	if ctx.Paused {
+		resumeGame(ctx)
+	}
+
+	// 游戏已结束，停止全局游戏时钟
+	ctx.StopGameClock()
+	ctx.RunoffCandidates = nil
+
+	reason := ctx.FinishReason
+	if reason == "" {
+		reason = FINISH_REASON_NORMAL
+	}
+	ctx.FinishReason = ""
+
+	// 确定胜利方（超时结算时卧底方仍在场即视为卧底方胜利，中止时没有胜利方）
 	var winner string
 	spyAlive := ctx.IsSpyAlive()
 	blankAlive := ctx.IsBlankAlive()
 
-	if spyAlive || blankAlive {
+	switch {
+	case reason == FINISH_REASON_ABORTED:
+		winner = ""
+	case reason == FINISH_REASON_FINAL_GUESS:
 		winner = "卧底方"
-	} else {
+	case spyAlive || blankAlive:
+		winner = "卧底方"
+	default:
 		winner = "平民方"
 	}
 
@@ -889,6 +1606,7 @@ func (fsh *finishStageHandler) OnEnter(ctx *GameContext) {
 		RESP_GAME_RESULT,
 		GameResultResponse{
 			Winner:      winner,
+			Reason:      reason,
 			AnswerWord:  ctx.AnswerWord,
 			SpyWord:     ctx.SpyWord,
 			PlayerRoles: playerRoles,
@@ -900,6 +1618,7 @@ func (fsh *finishStageHandler) OnEnter(ctx *GameContext) {
 		"结束阶段：广播游戏结果",
 		zap.String("roomID", ctx.RoomID),
 		zap.String("winner", winner),
+		zap.String("reason", reason),
 		zap.String("answer_word", ctx.AnswerWord),
 		zap.String("spy_word", ctx.SpyWord),
 		zap.Int("player_count", len(ctx.Players)),
@@ -911,211 +1630,366 @@ func (fsh *finishStageHandler) OnEnter(ctx *GameContext) {
 		"结束阶段：广播游戏结果完成",
 		zap.String("roomID", ctx.RoomID),
 	)
+
+	// 等待管理员发起再来一局，超时无人发起则关闭房间
+	ctx.RematchPending = false
+	ctx.RematchOptOuts = make(map[string]bool)
+	ctx.SetTimeout(REMATCH_LINGER)
 }
 
 func (fsh *finishStageHandler) OnHandle(ctx *GameContext, req RequestWrapper) error {
 	// 允许在任何阶段接受 JoinGame 请求（作为观察者或重连）
 	if jreq := TryUnwrapJoinGameRequest(req); jreq != nil {
-		playerID := jreq.PlayerID
-		if playerID == "" {
-			playerID = GenID()[len(GenID())-8:]
-		}
-
-		player := Player{
-			ID:     playerID,
-			Name:   jreq.JoinerName,
-			RespCh: jreq.RespCh,
-		}
-
-		onPlayerJoin(ctx, player)
+		onJoinRequest(ctx, jreq)
 		return nil
 	}
 	// 处理退出请求
 	if req := TryUnwrapExitGameRequest(req); req != nil {
-		onPlayerExit(ctx, req.PlayerID, req.RespCh)
+		onPlayerExit(ctx, req)
 		return nil
 	}
 
-	// 结束阶段不处理其他任何请求
-	return errors.New("游戏已结束")
-}
-
-func (fsh *finishStageHandler) OnExit(ctx *GameContext) {
-	// 强制确定为 FINISHED 阶段，防止出现异常状态
-	ctx.GameStage = STAGE_FINISHED
-}
+	// 处理超时请求
+	if req := TryUnwrapTimeoutRequest(req); req != nil {
+		if req.Stage == STAGE_FINISHED {
+			if ctx.RematchPending {
+				// 再来一局窗口结束，保留玩家名单回到等待阶段
+				resetForRematch(ctx)
+				fsh.onSwitch(STAGE_WAITING)
+				return nil
+			}
+
+			// 无人发起再来一局，关闭房间
+			zap.L().Info("结束阶段：无人发起再来一局，关闭房间", zap.String("roomID", ctx.RoomID))
+			ctx.Closed = true
+			return nil
+		}
+	}
+
+	if req := TryUnwrapRematchRequest(req); req != nil {
+		adminPlayer := ctx.GetAdmin()
+		if adminPlayer == nil {
+			return errors.New("无法再来一局：当前没有管理员")
+		}
+
+		if adminPlayer.ID != req.ReqPlayerID {
+			return errors.New("无法再来一局：只有管理员可以发起")
+		}
+
+		if ctx.RematchPending {
+			return errors.New("已发起再来一局，请等待")
+		}
+
+		ctx.RematchPending = true
+		ctx.RematchOptOuts = make(map[string]bool)
+
+		ctx.BroadcastResp(WrapResponse(
+			RESP_REMATCH,
+			RematchNotification{
+				Started:       false,
+				WindowSeconds: int(REMATCH_WINDOW / time.Second),
+				OptedOutIDs:   []string{},
+				MasterID:      ctx.MasterID(),
+			},
+		))
+
+		// 在窗口期内玩家可以选择退出下一局
+		ctx.SetTimeout(REMATCH_WINDOW)
+
+		return nil
+	}
+
+	if req := TryUnwrapRematchOptOutRequest(req); req != nil {
+		if !ctx.RematchPending {
+			return errors.New("当前没有进行中的再来一局")
+		}
+
+		player, ok := ctx.Players[req.ReqPlayerID]
+		if !ok {
+			return errors.New("玩家不存在")
+		}
+
+		if player.Role == ROLE_ADMIN || player.Role == ROLE_OBSERVER {
+			return errors.New("管理员和观察者无需退出下一局")
+		}
+
+		ctx.RematchOptOuts[player.ID] = true
+
+		ctx.BroadcastResp(WrapResponse(
+			RESP_REMATCH_OPT_OUT,
+			RematchOptOutResponse{
+				PlayerID:   player.ID,
+				PlayerName: player.Name,
+			},
+		))
+
+		return nil
+	}
+
+	// 结束阶段不处理其他任何请求
+	return errors.New("游戏已结束")
+}
+
+func (fsh *finishStageHandler) OnExit(ctx *GameContext) {
+	ctx.ClearTimeout()
+}
+
+// resetForRematch 清空上一局的身份、词语、投票和轮次信息，保留玩家名单和连接
+func resetForRematch(ctx *GameContext) {
+	optedOutIDs := make([]string, 0, len(ctx.RematchOptOuts))
+
+	for _, p := range ctx.Players {
+		p.Word = ""
+
+		switch {
+		case ctx.RematchOptOuts[p.ID]:
+			// 选择退出的玩家以观察者身份留在房间
+			p.Role = ROLE_OBSERVER
+			optedOutIDs = append(optedOutIDs, p.ID)
+		case p.Role == ROLE_ADMIN || p.Role == ROLE_OBSERVER:
+			// 管理员和观察者保持原身份
+		default:
+			// 上一局的参与者（含被淘汰者）重新成为待分配玩家
+			p.Role = ROLE_UNSET
+		}
+	}
+
+	// 游戏中接任的管理员仍是参与者身份，回到等待阶段时重新入座，避免自己出词又参与游戏；
+	// 盲主持模式下尊重管理员退出下一局的选择
+	if admin := ctx.GetAdmin(); admin != nil && !(ctx.Settings.BlindHost && ctx.RematchOptOuts[admin.ID]) {
+		seatAdmin(ctx, admin)
+	}
+
+	ctx.Answer = ""
+	ctx.AnswerWord = ""
+	ctx.SpyWord = ""
+	ctx.WordList = make([]string, 0)
+
+	ctx.Round = 0
+	ctx.SpeakingOrder = make([]string, 0)
+	ctx.CurrentSpeakerIdx = 0
+	ctx.Votes = make(map[string]string)
+	ctx.RunoffCandidates = nil
+
+	ctx.RematchPending = false
+	ctx.RematchOptOuts = make(map[string]bool)
+
+	zap.L().Info(
+		"再来一局：已重置房间",
+		zap.String("roomID", ctx.RoomID),
+		zap.Strings("opted_out", optedOutIDs),
+	)
+
+	ctx.BroadcastResp(WrapResponse(
+		RESP_REMATCH,
+		RematchNotification{
+			Started:     true,
+			OptedOutIDs: optedOutIDs,
+			Players:     buildPublicPlayersList(ctx),
+			MasterID:    ctx.MasterID(),
+		},
+	))
+}
 
 func (fsh *finishStageHandler) SetOnSwitch(onSwitch func(string)) {
 	fsh.onSwitch = onSwitch
 }
 
-func onPlayerJoin(ctx *GameContext, player Player) {
-	// 一局游戏最多支持 8 个正常玩家（不包括管理员和观察者）
-	const PLAYER_THRESHOLD = 8
-
-	// 如果存在相同的玩家 ID，则视为按 ID 重连：替换 RespCh 并发送快照
-	if existingPlayer, exists := ctx.Players[player.ID]; exists {
-		zap.L().Info(
-			"检测到相同 player ID，执行按 ID 重连",
-			zap.String("player_id", player.ID),
-			zap.String("player_name", player.Name),
+// onJoinRequest 将 JoinGame 请求转换为玩家并执行加入逻辑，所有阶段共用
+func onJoinRequest(ctx *GameContext, req *JoinGameRequest) {
+	if req.RemoteIP != "" && ctx.BannedIPs[req.RemoteIP] {
+		zap.L().Warn(
+			"封禁的 IP 尝试加入，拒绝",
+			zap.String("room_id", ctx.RoomID),
+			zap.String("remote_ip", req.RemoteIP),
 		)
 
-		// 关闭旧连接的响应通道，让旧的写协程退出
-		if existingPlayer.RespCh != nil {
-			close(existingPlayer.RespCh)
-			zap.L().Debug(
-				"已关闭旧连接的响应通道（按 ID 重连）",
-				zap.String("player_id", player.ID),
+		rejectJoin(req.RespCh, "你已被禁止加入该房间")
+		return
+	}
+
+	// 携带重连凭证：恢复凭证绑定的席位，不再按昵称或客户端提供的 ID 识别玩家
+	if req.ReconnectToken != "" {
+		playerID, ok := parseReconnectToken(ctx.ReconnectKey, ctx.RoomID, req.ReconnectToken)
+
+		existingPlayer, exists := ctx.Players[playerID]
+		if !ok || !exists {
+			zap.L().Warn(
+				"重连凭证无效，拒绝加入",
+				zap.String("room_id", ctx.RoomID),
+				zap.String("joiner_name", req.JoinerName),
 			)
+
+			rejectJoin(req.RespCh, "重连凭证无效")
+			return
 		}
 
-		// 更新为新连接的响应通道，保留原有的玩家ID和角色等信息
-		existingPlayer.RespCh = player.RespCh
+		if ctx.BannedIDs[playerID] {
+			rejectJoin(req.RespCh, "你已被禁止加入该房间")
+			return
+		}
 
-		// 1. 先给重连者私发完整信息（包含自己的 word 和 role）
-		privateResp := WrapResponse(
-			RESP_JOIN_GAME,
-			JoinGameResponse{
-				RoomID:   ctx.RoomID,
-				Stage:    ctx.GameStage,
-				Joiner:   *existingPlayer, // 完整信息
-				Players:  buildPublicPlayersList(ctx),
-				MasterID: ctx.GetAdmin().ID,
-			},
-		)
+		existingPlayer.RemoteIP = req.RemoteIP
 
-		select {
-		case existingPlayer.RespCh <- privateResp:
-			zap.L().Debug(
-				"成功发送按 ID 重连者私有快照",
-				zap.String("player_id", player.ID),
-			)
-		default:
-			zap.L().Warn("发送按 ID 重连者私有快照失败：通道已满")
-		}
-
-		// 2. 广播给所有人公开版本（隐藏重连者的敏感信息）
-		publicJoiner := sanitizePlayer(existingPlayer)
-		publicBroadcast := WrapResponse(
-			RESP_JOIN_GAME,
-			JoinGameResponse{
-				RoomID:   ctx.RoomID,
-				Stage:    ctx.GameStage,
-				Joiner:   publicJoiner, // 清理后的公开信息
-				Players:  buildPublicPlayersList(ctx),
-				MasterID: ctx.GetAdmin().ID,
-			},
-		)
+		onPlayerReconnect(ctx, existingPlayer, req.RespCh)
+		return
+	}
 
-		ctx.BroadcastResp(publicBroadcast)
+	player := Player{
+		ID:       GenID(),
+		Name:     uniquePlayerName(ctx, req.JoinerName),
+		RespCh:   req.RespCh,
+		JoinedAt: time.Now(),
+		RemoteIP: req.RemoteIP,
+	}
 
+	if player.Name != req.JoinerName {
 		zap.L().Info(
-			"按 ID 断线重连成功",
-			zap.String("player_id", player.ID),
-			zap.String("player_name", player.Name),
+			"昵称已被占用，自动添加后缀",
+			zap.String("room_id", ctx.RoomID),
+			zap.String("requested_name", req.JoinerName),
+			zap.String("assigned_name", player.Name),
 		)
+	}
 
-		return
+	// 如果客户端显式请求作为观察者，优先保留该身份
+	if req.Observer {
+		player.Role = ROLE_OBSERVER
 	}
 
-	// 检查是否存在相同的玩家 Name（断线重连场景）
-	for existingID, existingPlayer := range ctx.Players {
-		if existingPlayer.Name == player.Name {
-			zap.L().Info(
-				"检测到同名玩家，执行断线重连",
-				zap.String("player_id", existingID),
-				zap.String("player_name", player.Name),
-			)
+	onPlayerJoin(ctx, player, req.CreatorToken)
+}
 
-			// 关闭旧连接的响应通道，让旧的写协程退出
-			if existingPlayer.RespCh != nil {
-				close(existingPlayer.RespCh)
-				zap.L().Debug(
-					"已关闭旧连接的响应通道",
-					zap.String("player_id", existingID),
-				)
+// rejectJoin 向尚未加入房间的连接回复错误
+func rejectJoin(respCh chan ResponseWrapper, errMsg string) {
+	select {
+	case respCh <- WrapErrResponse(errMsg):
+	default:
+		zap.L().Warn("发送加入失败响应失败：通道已满")
+	}
+}
+
+// uniquePlayerName 昵称已被房间内其他玩家占用时，自动添加 (2)、(3)... 后缀
+func uniquePlayerName(ctx *GameContext, name string) string {
+	taken := func(candidate string) bool {
+		for _, p := range ctx.Players {
+			if p.Name == candidate {
+				return true
 			}
+		}
+		return false
+	}
 
-			// 更新为新连接的响应通道，保留原有的玩家ID和角色等信息
-			existingPlayer.RespCh = player.RespCh
-
-			// 1. 先给重连者私发完整信息（包含自己的 word 和 role）
-			privateResp := WrapResponse(
-				RESP_JOIN_GAME,
-				JoinGameResponse{
-					RoomID:   ctx.RoomID,
-					Stage:    ctx.GameStage,
-					Joiner:   *existingPlayer, // 完整信息
-					Players:  buildPublicPlayersList(ctx),
-					MasterID: ctx.GetAdmin().ID,
-				},
-			)
+	if !taken(name) {
+		return name
+	}
 
-			select {
-			case existingPlayer.RespCh <- privateResp:
-				zap.L().Debug(
-					"成功发送重连者私有快照",
-					zap.String("player_id", existingID),
-				)
-			default:
-				zap.L().Warn("发送重连者私有快照失败：通道已满")
-			}
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s(%d)", name, i)
+		if !taken(candidate) {
+			return candidate
+		}
+	}
+}
 
-			// 2. 广播给所有人公开版本（隐藏重连者的敏感信息）
-			publicJoiner := sanitizePlayer(existingPlayer)
-			publicBroadcast := WrapResponse(
-				RESP_JOIN_GAME,
-				JoinGameResponse{
-					RoomID:   ctx.RoomID,
-					Stage:    ctx.GameStage,
-					Joiner:   publicJoiner, // 清理后的公开信息
-					Players:  buildPublicPlayersList(ctx),
-					MasterID: ctx.GetAdmin().ID,
-				},
-			)
+// onPlayerReconnect 凭重连凭证恢复席位：替换响应通道，保留原有的玩家 ID、身份和词语
+func onPlayerReconnect(ctx *GameContext, existingPlayer *Player, respCh chan ResponseWrapper) {
+	zap.L().Info(
+		"凭重连凭证恢复席位",
+		zap.String("player_id", existingPlayer.ID),
+		zap.String("player_name", existingPlayer.Name),
+	)
 
-			ctx.BroadcastResp(publicBroadcast)
+	// 关闭旧连接的响应通道，让旧的写协程退出
+	if existingPlayer.RespCh != nil {
+		close(existingPlayer.RespCh)
+		zap.L().Debug(
+			"已关闭旧连接的响应通道",
+			zap.String("player_id", existingPlayer.ID),
+		)
+	}
 
-			zap.L().Info(
-				"断线重连成功",
-				zap.String("player_id", existingID),
-				zap.String("player_name", player.Name),
-			)
+	existingPlayer.RespCh = respCh
+
+	// 断线宽限期内重连，恢复在线状态
+	if existingPlayer.Disconnected {
+		ctx.StopDisconnectGrace(existingPlayer.ID)
+		existingPlayer.Disconnected = false
+	}
 
+	announceJoin(ctx, existingPlayer)
+
+	zap.L().Info(
+		"断线重连成功",
+		zap.String("player_id", existingPlayer.ID),
+		zap.String("player_name", existingPlayer.Name),
+	)
+}
+
+func onPlayerJoin(ctx *GameContext, player Player, creatorToken string) {
+	// 一局游戏的正常玩家上限由房间设置决定（不包括管理员和观察者）
+	playerThreshold := ctx.Settings.MaxPlayers
+
+	// 房主已经在房间内（例如打开了第二个页面）时视为重连，不重复入座，也不降级自己的管理员席位
+	if ctx.IsCreatorToken(creatorToken) {
+		if creator, ok := ctx.Players[ctx.CreatorID]; ok {
+			creator.RemoteIP = player.RemoteIP
+
+			onPlayerReconnect(ctx, creator, player.RespCh)
 			return
 		}
 	}
 
-	// 检查当前房间是否没有玩家，如果没有玩家，则加入的玩家成为管理员
-	if len(ctx.Players) == 0 {
-		player.Role = ROLE_ADMIN
+	// 携带房主凭证加入等待阶段的玩家成为管理员
+	if ctx.GameStage == STAGE_WAITING && ctx.IsCreatorToken(creatorToken) {
+		ctx.StopCreatorGrace()
 
-		ctx.Players[player.ID] = &player
-
-		// 广播玩家加入消息（首位玩家，包含完整房间状态）
-		joinResp := WrapResponse(
-			RESP_JOIN_GAME,
-			JoinGameResponse{
-				RoomID:   ctx.RoomID,
-				Stage:    ctx.GameStage,
-				Joiner:   player,
-				Players:  buildPublicPlayersList(ctx),
-				MasterID: player.ID,
-			},
-		)
+		// 宽限期后若已有代理管理员，由房主收回管理员席位，代理管理员回到普通玩家
+		if prevAdmin := ctx.GetAdmin(); prevAdmin != nil && prevAdmin.Role == ROLE_ADMIN {
+			if ctx.CountAlive() >= playerThreshold {
+				prevAdmin.Role = ROLE_OBSERVER
+			} else {
+				prevAdmin.Role = ROLE_UNSET
+			}
+
+			zap.L().Info(
+				"房主加入，收回代理管理员席位",
+				zap.String("room_id", ctx.RoomID),
+				zap.String("prev_admin_id", prevAdmin.ID),
+			)
+		}
+
+		seatAdmin(ctx, &player)
+		ctx.AdminID = player.ID
+		ctx.CreatorID = player.ID
+		if ctx.CoHostID == player.ID {
+			ctx.CoHostID = ""
+		}
+
+		admitPlayer(ctx, &player)
+
+		return
+	}
+
+	// 房主宽限期已过且当前没有管理员：首个加入等待阶段的非观察者成为管理员
+	if ctx.GameStage == STAGE_WAITING &&
+		ctx.CreatorGraceExpired &&
+		ctx.GetAdmin() == nil &&
+		!isObserverLike(player.Role) {
+		seatAdmin(ctx, &player)
+		ctx.AdminID = player.ID
 
-		ctx.BroadcastResp(joinResp)
+		admitPlayer(ctx, &player)
 
 		return
 	}
 
 	// 检查当前的游戏是否已经满员（按存活玩家计数，不含管理员/观察者）
-	if ctx.CountAlive() >= PLAYER_THRESHOLD {
+	if ctx.CountAlive() >= playerThreshold {
 		// 超过玩家上限，默认变为观察者身份
 		player.Role = ROLE_OBSERVER
 
-		ctx.Players[player.ID] = &player
+		admitPlayer(ctx, &player)
 
 		return
 	}
@@ -1124,31 +1998,12 @@ func onPlayerJoin(ctx *GameContext, player Player) {
 
 	if ctx.GameStage == STAGE_WAITING {
 		// 如果是等待阶段，则玩家可以直接进入游戏
-		// 第一个加入的玩家成为管理员
-		if len(ctx.Players) == 0 {
-			player.Role = ROLE_ADMIN
-		} else {
-			// 如果客户端显式请求观察者，优先保留；否则成为未设置的普通玩家
-			if !isObserverLike(player.Role) {
-				player.Role = ROLE_UNSET
-			}
+		// 如果客户端显式请求观察者，优先保留；否则成为未设置的普通玩家
+		if !isObserverLike(player.Role) {
+			player.Role = ROLE_UNSET
 		}
 
-		ctx.Players[player.ID] = &player
-
-		// 广播玩家加入消息（等待阶段，包含完整房间状态）
-		joinResp := WrapResponse(
-			RESP_JOIN_GAME,
-			JoinGameResponse{
-				RoomID:   ctx.RoomID,
-				Stage:    ctx.GameStage,
-				Joiner:   player,
-				Players:  buildPublicPlayersList(ctx),
-				MasterID: ctx.GetAdmin().ID,
-			},
-		)
-
-		ctx.BroadcastResp(joinResp)
+		admitPlayer(ctx, &player)
 
 		return
 	}
@@ -1156,90 +2011,755 @@ func onPlayerJoin(ctx *GameContext, player Player) {
 	// 否则，玩家只能以观察者身份加入游戏
 	player.Role = ROLE_OBSERVER
 
-	ctx.Players[player.ID] = &player
+	admitPlayer(ctx, &player)
+}
+
+// admitPlayer 将新玩家加入房间并通知所有人
+func admitPlayer(ctx *GameContext, player *Player) {
+	ctx.Players[player.ID] = player
+
+	announceJoin(ctx, player)
+}
+
+// announceJoin 先给加入者私发完整快照（包含自己的身份、词语和重连凭证），
+// 再向其他玩家广播隐藏敏感信息的公开版本
+func announceJoin(ctx *GameContext, joiner *Player) {
+	// 私有快照中的身份同样遵循不知身份模式的隐藏规则
+	self := *joiner
+	self.Role = toPrivateRole(ctx.Settings, joiner.Role)
 
-	// 广播玩家加入消息（观察者加入，包含完整房间状态）
-	joinResp := WrapResponse(
+	privateResp := buildJoinResp(ctx, self, ctx.ReconnectToken(joiner.ID))
+
+	select {
+	case joiner.RespCh <- privateResp:
+		zap.L().Debug(
+			"成功发送加入者私有快照",
+			zap.String("player_id", joiner.ID),
+		)
+	default:
+		zap.L().Warn("发送加入者私有快照失败：通道已满")
+	}
+
+	publicResp := buildJoinResp(ctx, sanitizePlayer(joiner), "")
+
+	ctx.BroadcastRespExcept(publicResp, joiner.ID)
+}
+
+// buildJoinResp 构造包含完整房间状态的 JoinGame 响应，重连凭证只出现在发给加入者本人的响应中
+func buildJoinResp(ctx *GameContext, joiner Player, reconnectToken string) ResponseWrapper {
+	return WrapResponse(
 		RESP_JOIN_GAME,
 		JoinGameResponse{
-			RoomID:   ctx.RoomID,
-			Stage:    ctx.GameStage,
-			Joiner:   player,
-			Players:  buildPublicPlayersList(ctx),
-			MasterID: ctx.GetAdmin().ID,
+			RoomID:         ctx.RoomID,
+			Stage:          ctx.GameStage,
+			Joiner:         joiner,
+			Players:        buildPublicPlayersList(ctx),
+			MasterID:       ctx.MasterID(),
+			CoHostID:       ctx.CoHostID,
+			Paused:         ctx.Paused,
+			Settings:       ctx.Settings,
+			ReconnectToken: reconnectToken,
 		},
 	)
+}
+
+// onCreatorGraceTimeout 房主宽限期结束仍未加入时，将最早加入且在线的等待玩家提升为管理员；
+// 若房间内尚无玩家，则由之后首个加入的玩家成为管理员
+func onCreatorGraceTimeout(ctx *GameContext) {
+	ctx.CreatorGraceExpired = true
+
+	if ctx.GetAdmin() != nil {
+		return
+	}
+
+	var candidate *Player
+	for _, p := range ctx.Players {
+		if p.Role != ROLE_UNSET || p.RespCh == nil {
+			continue
+		}
+
+		if candidate == nil || p.JoinedAt.Before(candidate.JoinedAt) {
+			candidate = p
+		}
+	}
+
+	if candidate == nil {
+		zap.L().Info(
+			"房主宽限期结束，等待首个加入的玩家成为管理员",
+			zap.String("room_id", ctx.RoomID),
+		)
+		return
+	}
+
+	seatAdmin(ctx, candidate)
+	ctx.AdminID = candidate.ID
+
+	zap.L().Info(
+		"房主宽限期结束，提升最早加入的玩家为管理员",
+		zap.String("room_id", ctx.RoomID),
+		zap.String("player_id", candidate.ID),
+	)
 
-	ctx.BroadcastResp(joinResp)
+	broadcastMasterChanged(ctx, MASTER_CHANGE_CREATOR_ABSENT)
 }
 
-func onPlayerExit(ctx *GameContext, playerID string, reqRespCh chan ResponseWrapper) {
-	player, exists := ctx.Players[playerID]
+// broadcastMasterChanged 广播当前的管理员和副管理员
+func broadcastMasterChanged(ctx *GameContext, reason string) {
+	notif := MasterChangedNotification{
+		CoHostID: ctx.CoHostID,
+		Reason:   reason,
+	}
+
+	if admin := ctx.GetAdmin(); admin != nil {
+		notif.MasterID = admin.ID
+		notif.MasterName = admin.Name
+	}
+
+	ctx.BroadcastResp(WrapResponse(RESP_MASTER_CHANGED, notif))
+}
+
+// handleGlobalRequest 处理与阶段无关的请求：全局游戏时钟、断线宽限期以及管理员移交。
+// 返回 false 表示该请求应交给当前阶段处理
+func handleGlobalRequest(ctx *GameContext, req RequestWrapper) (bool, error) {
+	if ctx.Paused {
+		if handled, err := rejectWhilePaused(ctx, req); handled {
+			return true, err
+		}
+	}
+
+	if tmo := TryUnwrapTimeoutRequest(req); tmo != nil {
+		switch tmo.Kind {
+		case TIMEOUT_STAGE:
+			// 定时器被清除或重设前已经触发的超时事件，直接丢弃
+			if tmo.Seq != ctx.TimerSeq {
+				zap.L().Debug(
+					"丢弃过期的阶段超时事件",
+					zap.String("room_id", ctx.RoomID),
+					zap.String("stage", tmo.Stage),
+				)
+				return true, nil
+			}
+		case TIMEOUT_GAME_CLOCK:
+			onGameClockTimeout(ctx)
+			return true, nil
+		case TIMEOUT_DISCONNECT_GRACE:
+			onDisconnectGraceTimeout(ctx, tmo.PlayerID)
+			return true, nil
+		}
+
+		return false, nil
+	}
+
+	if req := TryUnwrapTransferAdminRequest(req); req != nil {
+		return true, onTransferAdmin(ctx, req)
+	}
+
+	if req := TryUnwrapSetCoHostRequest(req); req != nil {
+		return true, onSetCoHost(ctx, req)
+	}
+
+	if req := TryUnwrapKickPlayerRequest(req); req != nil {
+		return true, onKickPlayer(ctx, req.ReqPlayerID, req.TargetID, false)
+	}
+
+	if req := TryUnwrapBanPlayerRequest(req); req != nil {
+		return true, onKickPlayer(ctx, req.ReqPlayerID, req.TargetID, true)
+	}
+
+	if req := TryUnwrapMutePlayerRequest(req); req != nil {
+		return true, onMutePlayer(ctx, req)
+	}
+
+	if req := TryUnwrapPauseGameRequest(req); req != nil {
+		return true, onPauseGame(ctx, req)
+	}
+
+	if req := TryUnwrapResumeGameRequest(req); req != nil {
+		return true, onResumeGame(ctx, req)
+	}
+
+	if req := TryUnwrapSkipSpeakerRequest(req); req != nil {
+		return true, onSkipSpeaker(ctx, req)
+	}
+
+	if req := TryUnwrapEndVotingRequest(req); req != nil {
+		return true, onEndVoting(ctx, req)
+	}
+
+	if req := TryUnwrapExtendTimerRequest(req); req != nil {
+		return true, onExtendTimer(ctx, req)
+	}
+
+	if req := TryUnwrapAbortGameRequest(req); req != nil {
+		return true, onAbortGame(ctx, req)
+	}
+
+	return false, nil
+}
+
+// rejectWhilePaused 暂停期间丢弃已经触发的游戏计时事件，并拒绝发言和投票
+func rejectWhilePaused(ctx *GameContext, req RequestWrapper) (bool, error) {
+	switch req.ReqType {
+	case REQ_TIMEOUT:
+		// 暂停前已经触发但尚未处理的计时事件，恢复时会按剩余时长重新计时
+		if tmo := TryUnwrapTimeoutRequest(req); tmo != nil && tmo.Kind != TIMEOUT_CREATOR_GRACE {
+			return true, nil
+		}
+	case REQ_DESCRIBE, REQ_VOTE, REQ_GUESS_WORD:
+		err := errors.New("游戏已暂停")
+		ctx.UnicastResp(req.SenderID, WrapErrResponse(err.Error()))
+		return true, err
+	}
+
+	return false, nil
+}
+
+// onPauseGame 管理员暂停游戏，冻结所有计时
+func onPauseGame(ctx *GameContext, req *PauseGameRequest) error {
+	if !isAdmin(ctx, req.ReqPlayerID) {
+		return errors.New("无法暂停游戏：只有管理员可以暂停")
+	}
+
+	if !isInGame(ctx) {
+		return errors.New("无法暂停游戏：只能在游戏进行中暂停")
+	}
+
+	if ctx.Paused {
+		return errors.New("游戏已经处于暂停状态")
+	}
+
+	ctx.PauseTimers()
+
+	zap.L().Info(
+		"游戏已暂停",
+		zap.String("room_id", ctx.RoomID),
+		zap.String("stage", ctx.GameStage),
+		zap.Duration("stage_left", ctx.PausedStageLeft),
+	)
+
+	broadcastPauseState(ctx)
+
+	return nil
+}
+
+// onResumeGame 管理员恢复游戏，按暂停时的剩余时长继续计时
+func onResumeGame(ctx *GameContext, req *ResumeGameRequest) error {
+	if !isAdmin(ctx, req.ReqPlayerID) {
+		return errors.New("无法恢复游戏：只有管理员可以恢复")
+	}
+
+	if !ctx.Paused {
+		return errors.New("游戏没有处于暂停状态")
+	}
+
+	resumeGame(ctx)
+
+	return nil
+}
+
+// resumeGame 恢复计时并广播；管理员恢复、暂停期间无人可接任管理员或游戏结束时调用
+func resumeGame(ctx *GameContext) {
+	ctx.ResumeTimers()
+
+	zap.L().Info(
+		"游戏已恢复",
+		zap.String("room_id", ctx.RoomID),
+		zap.String("stage", ctx.GameStage),
+	)
+
+	broadcastPauseState(ctx)
+}
+
+func broadcastPauseState(ctx *GameContext) {
+	var remaining time.Duration
+	switch {
+	case ctx.Paused && ctx.PausedStageLeft > 0:
+		remaining = ctx.PausedStageLeft
+	case !ctx.Paused && ctx.Timer != nil:
+		remaining = time.Until(ctx.TimerDeadline)
+	}
+
+	ctx.BroadcastResp(WrapResponse(
+		RESP_GAME_PAUSED,
+		GamePausedNotification{
+			Paused:           ctx.Paused,
+			Stage:            ctx.GameStage,
+			RemainingSeconds: int((remaining + time.Second - 1) / time.Second),
+		},
+	))
+}
+
+// isAdmin 判断玩家是否为当前管理员
+func isAdmin(ctx *GameContext, playerID string) bool {
+	admin := ctx.GetAdmin()
+	return admin != nil && admin.ID == playerID
+}
+
+// isInGame 判断当前是否处于游戏进行中的阶段
+func isInGame(ctx *GameContext) bool {
+	switch ctx.GameStage {
+	case STAGE_PREPARING, STAGE_SPEAKING, STAGE_VOTING, STAGE_JUDGING:
+		return true
+	default:
+		return false
+	}
+}
+
+func broadcastOverride(ctx *GameContext, notif AdminOverrideNotification) {
+	notif.Stage = ctx.GameStage
+
+	ctx.BroadcastResp(WrapResponse(RESP_ADMIN_OVERRIDE, notif))
+}
+
+// onSkipSpeaker 管理员跳过当前发言者，与发言超时的处理相同
+func onSkipSpeaker(ctx *GameContext, req *SkipSpeakerRequest) error {
+	if !isAdmin(ctx, req.ReqPlayerID) {
+		return errors.New("无法跳过发言：只有管理员可以跳过")
+	}
+
+	if ctx.GameStage != STAGE_SPEAKING || ctx.CurrentSpeakerIdx >= len(ctx.SpeakingOrder) {
+		return errors.New("无法跳过发言：当前不在发言阶段")
+	}
+
+	speaker := ctx.Players[ctx.SpeakingOrder[ctx.CurrentSpeakerIdx]]
+
+	broadcastOverride(ctx, AdminOverrideNotification{
+		Action: OVERRIDE_SKIP_SPEAKER,
+		Target: &PlayerRef{ID: speaker.ID, Name: speaker.Name},
+	})
+
+	advanceSpeaker(ctx)
+
+	return nil
+}
+
+// onEndVoting 管理员提前结束投票，直接进入判定阶段
+func onEndVoting(ctx *GameContext, req *EndVotingRequest) error {
+	if !isAdmin(ctx, req.ReqPlayerID) {
+		return errors.New("无法结束投票：只有管理员可以结束投票")
+	}
+
+	if ctx.GameStage != STAGE_VOTING {
+		return errors.New("无法结束投票：当前不在投票阶段")
+	}
+
+	broadcastOverride(ctx, AdminOverrideNotification{
+		Action: OVERRIDE_END_VOTING,
+	})
+
+	// 直接修改阶段，状态机会在本次事件处理后检测到变化并切换
+	ctx.GameStage = STAGE_JUDGING
+
+	return nil
+}
+
+// onExtendTimer 管理员延长当前阶段的计时，暂停期间延长暂停时记录的剩余时长
+func onExtendTimer(ctx *GameContext, req *ExtendTimerRequest) error {
+	if !isAdmin(ctx, req.ReqPlayerID) {
+		return errors.New("无法延长计时：只有管理员可以延长")
+	}
+
+	if !isInGame(ctx) {
+		return errors.New("无法延长计时：只能在游戏进行中延长")
+	}
+
+	if req.Seconds < 1 || req.Seconds > TIMING_STAGE_UPPER {
+		return fmt.Errorf("无法延长计时：延长秒数必须在 1 到 %d 之间", TIMING_STAGE_UPPER)
+	}
+
+	extend := time.Duration(req.Seconds) * time.Second
+
+	var remaining time.Duration
+	switch {
+	case ctx.Paused && ctx.PausedStageLeft >= 0:
+		ctx.PausedStageLeft += extend
+		remaining = ctx.PausedStageLeft
+	case !ctx.Paused && ctx.Timer != nil:
+		remaining = max(time.Until(ctx.TimerDeadline), 0) + extend
+		ctx.SetTimeout(remaining)
+	default:
+		return errors.New("无法延长计时：当前阶段没有计时")
+	}
+
+	broadcastOverride(ctx, AdminOverrideNotification{
+		Action:           OVERRIDE_EXTEND_TIMER,
+		ExtendSeconds:    req.Seconds,
+		RemainingSeconds: int((remaining + time.Second - 1) / time.Second),
+	})
+
+	return nil
+}
+
+// onAbortGame 管理员中止游戏，直接进入结束阶段并公开所有身份，不判定胜负
+func onAbortGame(ctx *GameContext, req *AbortGameRequest) error {
+	if !isAdmin(ctx, req.ReqPlayerID) {
+		return errors.New("无法中止游戏：只有管理员可以中止")
+	}
+
+	if !isInGame(ctx) {
+		return errors.New("无法中止游戏：游戏没有在进行中")
+	}
+
+	zap.L().Info(
+		"管理员中止游戏",
+		zap.String("room_id", ctx.RoomID),
+		zap.String("stage", ctx.GameStage),
+	)
+
+	broadcastOverride(ctx, AdminOverrideNotification{
+		Action: OVERRIDE_ABORT,
+	})
+
+	ctx.FinishReason = FINISH_REASON_ABORTED
+	// 直接修改阶段，状态机会在本次事件处理后检测到变化并切换
+	ctx.GameStage = STAGE_FINISHED
+
+	return nil
+}
+
+// onTransferAdmin 管理员主动将管理员移交给另一名在线玩家
+func onTransferAdmin(ctx *GameContext, req *TransferAdminRequest) error {
+	admin := ctx.GetAdmin()
+	if admin == nil || admin.ID != req.ReqPlayerID {
+		return errors.New("无法移交管理员：只有管理员可以移交")
+	}
+
+	target, ok := ctx.Players[req.TargetID]
+	if !ok {
+		return errors.New("无法移交管理员：目标玩家不存在")
+	}
+
+	if target.ID == admin.ID {
+		return errors.New("无法移交管理员：不能移交给自己")
+	}
+
+	if target.RespCh == nil {
+		return errors.New("无法移交管理员：目标玩家不在线")
+	}
+
+	assignAdmin(ctx, target)
+
+	// 原管理员让出管理员席位：等待阶段回到普通玩家（满员时为观察者），游戏中只能观战
+	if admin.Role == ROLE_ADMIN {
+		if ctx.GameStage == STAGE_WAITING && ctx.CountAlive() < ctx.Settings.MaxPlayers {
+			admin.Role = ROLE_UNSET
+		} else {
+			admin.Role = ROLE_OBSERVER
+		}
+	}
+
+	zap.L().Info(
+		"管理员已移交",
+		zap.String("room_id", ctx.RoomID),
+		zap.String("from", admin.ID),
+		zap.String("to", target.ID),
+	)
+
+	broadcastMasterChanged(ctx, MASTER_CHANGE_TRANSFER)
+
+	return nil
+}
+
+// onSetCoHost 管理员指定副管理员，管理员离开时优先由副管理员接任；目标为空表示取消
+func onSetCoHost(ctx *GameContext, req *SetCoHostRequest) error {
+	admin := ctx.GetAdmin()
+	if admin == nil || admin.ID != req.ReqPlayerID {
+		return errors.New("无法设置副管理员：只有管理员可以设置")
+	}
+
+	if req.TargetID != "" {
+		target, ok := ctx.Players[req.TargetID]
+		if !ok {
+			return errors.New("无法设置副管理员：目标玩家不存在")
+		}
+
+		if target.ID == admin.ID {
+			return errors.New("无法设置副管理员：不能指定自己")
+		}
+
+		if target.RespCh == nil {
+			return errors.New("无法设置副管理员：目标玩家不在线")
+		}
+	}
+
+	ctx.CoHostID = req.TargetID
+
+	broadcastMasterChanged(ctx, MASTER_CHANGE_CO_HOST)
+
+	return nil
+}
+
+// moderationTarget 校验管理操作的发起者是管理员，并返回目标玩家
+func moderationTarget(ctx *GameContext, reqPlayerID string, targetID string) (*Player, error) {
+	admin := ctx.GetAdmin()
+	if admin == nil || admin.ID != reqPlayerID {
+		return nil, errors.New("只有管理员可以执行该操作")
+	}
+
+	target, ok := ctx.Players[targetID]
+	if !ok {
+		return nil, errors.New("目标玩家不存在")
+	}
+
+	if target.ID == admin.ID {
+		return nil, errors.New("不能对自己执行该操作")
+	}
+
+	return target, nil
+}
+
+// onKickPlayer 将玩家踢出房间；ban 为 true 时同时封禁其玩家 ID（重连凭证随之失效）和 IP
+func onKickPlayer(ctx *GameContext, reqPlayerID string, targetID string, ban bool) error {
+	target, err := moderationTarget(ctx, reqPlayerID, targetID)
+	if err != nil {
+		return fmt.Errorf("无法踢出玩家：%w", err)
+	}
+
+	action := MODERATION_KICK
+	if ban {
+		action = MODERATION_BAN
+
+		ctx.BannedIDs[target.ID] = true
+		if target.RemoteIP != "" {
+			ctx.BannedIPs[target.RemoteIP] = true
+		}
+	}
+
+	zap.L().Info(
+		"管理员踢出玩家",
+		zap.String("room_id", ctx.RoomID),
+		zap.String("player_id", target.ID),
+		zap.String("action", action),
+	)
+
+	// 先通知包括目标在内的所有人，之后目标的连接会被关闭
+	ctx.BroadcastResp(WrapResponse(
+		RESP_MODERATION,
+		ModerationNotification{
+			Action:     action,
+			TargetID:   target.ID,
+			TargetName: target.Name,
+		},
+	))
+
+	// 按离开处理，游戏中同样调整发言顺序、投票人数并重新检查胜负，随后从房间中移除
+	onPlayerLeave(ctx, target)
+
+	delete(ctx.Players, target.ID)
+	delete(ctx.RematchOptOuts, target.ID)
+
+	return nil
+}
+
+// onMutePlayer 禁言或解除禁言，被禁言的玩家不能发送 Describe
+func onMutePlayer(ctx *GameContext, req *MutePlayerRequest) error {
+	target, err := moderationTarget(ctx, req.ReqPlayerID, req.TargetID)
+	if err != nil {
+		return fmt.Errorf("无法禁言玩家：%w", err)
+	}
+
+	if target.Muted == req.Muted {
+		return nil
+	}
+
+	target.Muted = req.Muted
+
+	action := MODERATION_MUTE
+	if !req.Muted {
+		action = MODERATION_UNMUTE
+	}
+
+	ctx.BroadcastResp(WrapResponse(
+		RESP_MODERATION,
+		ModerationNotification{
+			Action:     action,
+			TargetID:   target.ID,
+			TargetName: target.Name,
+		},
+	))
+
+	return nil
+}
+
+// assignAdmin 将管理员交给指定玩家：等待阶段接任者占用管理员席位，游戏中保留原有身份
+func assignAdmin(ctx *GameContext, player *Player) {
+	ctx.AdminID = player.ID
+	if ctx.CoHostID == player.ID {
+		ctx.CoHostID = ""
+	}
+
+	if ctx.GameStage == STAGE_WAITING && (player.Role == ROLE_UNSET || player.Role == ROLE_OBSERVER) {
+		seatAdmin(ctx, player)
+	}
+}
+
+// migrateAdmin 管理员离开后自动移交：优先在线的副管理员，其次最早加入的在线非观察者；
+// 无人可接任时由之后首个加入等待阶段的玩家成为管理员
+func migrateAdmin(ctx *GameContext) {
+	ctx.AdminID = ""
+
+	var candidate *Player
+	if coHost, ok := ctx.Players[ctx.CoHostID]; ok && coHost.RespCh != nil {
+		candidate = coHost
+	} else {
+		for _, p := range ctx.Players {
+			if p.RespCh == nil || isObserverLike(p.Role) {
+				continue
+			}
+
+			if candidate == nil || p.JoinedAt.Before(candidate.JoinedAt) {
+				candidate = p
+			}
+		}
+	}
+
+	if candidate == nil {
+		ctx.CreatorGraceExpired = true
+
+		zap.L().Info(
+			"管理员离开且无人可接任，等待首个加入的玩家成为管理员",
+			zap.String("room_id", ctx.RoomID),
+		)
+
+		// 没有人能够恢复游戏，自动恢复
+		if ctx.Paused {
+			resumeGame(ctx)
+		}
+	} else {
+		assignAdmin(ctx, candidate)
+
+		zap.L().Info(
+			"管理员离开，自动移交管理员",
+			zap.String("room_id", ctx.RoomID),
+			zap.String("player_id", candidate.ID),
+		)
+	}
+
+	broadcastMasterChanged(ctx, MASTER_CHANGE_ADMIN_LEFT)
+}
+
+func onPlayerExit(ctx *GameContext, req *ExitGameRequest) {
+	player, exists := ctx.Players[req.PlayerID]
 	if !exists {
 		zap.L().Warn(
 			"玩家不存在，无法退出",
-			zap.String("player_id", playerID),
+			zap.String("player_id", req.PlayerID),
 		)
 		return
 	}
 
-	playerName := player.Name
+	// 网络断开与主动退出区分处理：断线保留席位，主动退出立即离开
+	if req.Disconnected {
+		onPlayerDisconnect(ctx, player, req.RespCh)
+		return
+	}
+
+	onPlayerLeave(ctx, player)
+}
 
-	// 检查RespCh是否匹配，不匹配说明已经被顶替重连
-	if player.RespCh != reqRespCh {
+// onPlayerDisconnect 处理连接断开：在宽限期内保留玩家的身份、词语和席位，等待重连
+func onPlayerDisconnect(ctx *GameContext, player *Player, connRespCh chan ResponseWrapper) {
+	// 检查RespCh是否匹配，不匹配说明已经被顶替重连（或玩家已主动退出）
+	if player.RespCh != connRespCh {
 		zap.L().Info(
-			"检测到旧连接退出（已被顶替），只关闭旧通道不删除玩家",
-			zap.String("player_id", playerID),
-			zap.String("player_name", playerName),
+			"检测到旧连接断开（已被顶替或已退出），忽略",
+			zap.String("player_id", player.ID),
+			zap.String("player_name", player.Name),
 		)
 
-		// 发送退出确认给旧连接
-		exitResp := WrapResponse(
-			RESP_EXIT_GAME,
-			ExitGameResponse{
-				LeftPlayerID:   playerID,
-				LeftPlayerName: playerName,
-			},
-		)
+		// 旧连接的通道已经被顶替逻辑关闭，这里不能再向其发送或关闭
+		return
+	}
 
-		select {
-		case reqRespCh <- exitResp:
-			zap.L().Debug("发送退出确认给旧连接成功")
-		default:
-			zap.L().Debug("旧连接通道已满或关闭")
-		}
+	if ctx.DisconnectGrace <= 0 {
+		// 未启用断线宽限期，断线即离开
+		onPlayerLeave(ctx, player)
+		return
+	}
+
+	// 将玩家的响应通道置为 nil，避免 BroadcastResp 向其发送消息，并通知写协程退出
+	player.RespCh = nil
+	close(connRespCh)
+
+	player.Disconnected = true
+	player.DisconnectedAt = time.Now()
 
-		// 旧连接的通道可能已经被顶替逻辑关闭了，这里安全检查
-		// 不关闭 reqRespCh，因为可能已经被关闭了（会 panic）
+	ctx.StartDisconnectGrace(player.ID)
+
+	zap.L().Info(
+		"玩家断线，保留席位等待重连",
+		zap.String("player_id", player.ID),
+		zap.String("player_name", player.Name),
+		zap.Duration("grace", ctx.DisconnectGrace),
+	)
+
+	ctx.BroadcastResp(WrapResponse(
+		RESP_PLAYER_DISCONNECTED,
+		PlayerDisconnectedNotification{
+			PlayerID:     player.ID,
+			PlayerName:   player.Name,
+			GraceSeconds: int(ctx.DisconnectGrace / time.Second),
+		},
+	))
+}
+
+// onDisconnectGraceTimeout 断线宽限期结束仍未重连，玩家离开游戏
+func onDisconnectGraceTimeout(ctx *GameContext, playerID string) {
+	player, exists := ctx.Players[playerID]
+	if !exists || !player.Disconnected {
+		return
+	}
 
+	// 定时器可能在玩家重连后再次断线前已经触发，按断线时间过滤过期的超时事件
+	if time.Since(player.DisconnectedAt) < ctx.DisconnectGrace {
 		return
 	}
 
-	// 正常退出（客户端断开或服务器触发）
-	// 不要向离开的玩家发送任何广播型响应（服务器生成的 ExitGame 不应得到响应）
+	zap.L().Info(
+		"断线宽限期结束，玩家离开游戏",
+		zap.String("player_id", player.ID),
+		zap.String("player_name", player.Name),
+	)
+
+	onPlayerLeave(ctx, player)
+}
 
+// onPlayerLeave 玩家离开游戏：游戏中的参与者按淘汰处理（保留原身份供结算），其余玩家成为观察者
+func onPlayerLeave(ctx *GameContext, player *Player) {
 	// 保留旧通道引用，用于后续关闭写协程
 	oldCh := player.RespCh
+	wasParticipant := isParticipant(player.Role)
+	wasAdmin := player.ID == ctx.AdminID
+
+	if ctx.CoHostID == player.ID {
+		ctx.CoHostID = ""
+	}
 
 	// 将玩家的响应通道置为 nil，避免 BroadcastResp 向其发送消息
+	// 不要向离开的玩家发送任何广播型响应
 	player.RespCh = nil
 
+	ctx.StopDisconnectGrace(player.ID)
+	player.Disconnected = false
+
 	// 将玩家标记为观察者以保留信息，防止误删导致状态不一致
-	player.Role = ROLE_OBSERVER
-	player.Word = ""
+	player.Role = toEliminatedRole(player.Role)
+	if !isEliminatedRole(player.Role) {
+		player.Word = ""
+	}
 
 	zap.L().Info(
 		"玩家已退出游戏（标记为观察者）",
-		zap.String("player_id", playerID),
-		zap.String("player_name", playerName),
+		zap.String("player_id", player.ID),
+		zap.String("player_name", player.Name),
 	)
 
 	// 向其他玩家广播离开消息（不会发送到已置为 nil 的通道）
 	leftNotif := WrapResponse(
 		RESP_EXIT_GAME,
 		ExitGameResponse{
-			LeftPlayerID:   playerID,
-			LeftPlayerName: playerName,
+			LeftPlayerID:   player.ID,
+			LeftPlayerName: player.Name,
 		},
 	)
 
@@ -1249,4 +2769,76 @@ func onPlayerExit(ctx *GameContext, playerID string, reqRespCh chan ResponseWrap
 	if oldCh != nil {
 		close(oldCh)
 	}
+
+	if wasAdmin {
+		migrateAdmin(ctx)
+	}
+
+	if wasParticipant {
+		onParticipantDeparted(ctx, player.ID, FINISH_REASON_PLAYER_LEFT)
+	}
+}
+
+// onParticipantDeparted 游戏中的参与者离开或被淘汰后，立即重新检查胜负（分出胜负时以 reason 结束），并调整发言顺序和投票人数
+func onParticipantDeparted(ctx *GameContext, playerID string, reason string) {
+	switch ctx.GameStage {
+	case STAGE_PREPARING, STAGE_SPEAKING, STAGE_VOTING, STAGE_JUDGING:
+	default:
+		return
+	}
+
+	// 加时赛中的平票玩家离开后不再参与加时赛
+	if idx := slices.Index(ctx.RunoffCandidates, playerID); idx >= 0 {
+		ctx.RunoffCandidates = slices.Delete(ctx.RunoffCandidates, idx, idx+1)
+	}
+
+	if isGameDecided(ctx) {
+		ctx.FinishReason = reason
+		// 直接修改阶段，状态机会在本次事件处理后检测到变化并切换
+		ctx.GameStage = STAGE_FINISHED
+		return
+	}
+
+	switch ctx.GameStage {
+	case STAGE_SPEAKING:
+		idx := slices.Index(ctx.SpeakingOrder, playerID)
+		if idx < 0 {
+			return
+		}
+
+		ctx.SpeakingOrder = slices.Delete(ctx.SpeakingOrder, idx, idx+1)
+
+		if idx < ctx.CurrentSpeakerIdx {
+			ctx.CurrentSpeakerIdx--
+			return
+		}
+
+		if idx > ctx.CurrentSpeakerIdx {
+			return
+		}
+
+		// 当前发言者离开，不再等待发言超时，立即轮到下一位
+		if ctx.CurrentSpeakerIdx >= len(ctx.SpeakingOrder) {
+			ctx.GameStage = STAGE_VOTING
+			return
+		}
+
+		broadcastSpeakingState(ctx, ctx.Players[ctx.SpeakingOrder[ctx.CurrentSpeakerIdx]])
+		ctx.SetTimeout(ctx.Settings.Timing.Speak())
+
+	case STAGE_VOTING:
+		// 作废离开者的投票，以及投给离开者的票（这些投票者可以重新投票）
+		delete(ctx.Votes, playerID)
+		for voterID, targetID := range ctx.Votes {
+			if targetID == playerID {
+				delete(ctx.Votes, voterID)
+			}
+		}
+
+		// 按调整后的投票人数检查是否已全部投完
+		eligibleVoters := countEligibleVoters(ctx, ctx.RunoffCandidates)
+		if !ctx.Settings.AllowVoteChange && eligibleVoters > 0 && len(ctx.Votes) >= eligibleVoters {
+			ctx.GameStage = STAGE_JUDGING
+		}
+	}
 }
@@ -1,16 +1,19 @@
 package game
 
+import "time"
+
 // 玩家身份
 const (
-	ROLE_UNSET    = "Unset"
-	ROLE_ADMIN    = "Admin"
-	ROLE_NORMAL   = "Normal"
-	ROLE_BLANK    = "Blank"
-	ROLE_SPY      = "Spy"
-	ROLE_OBSERVER = "Observer"
+	ROLE_UNSET     = "Unset"
+	ROLE_ADMIN     = "Admin"
+	ROLE_NORMAL    = "Normal"
+	ROLE_BLANK     = "Blank"
+	ROLE_SPY       = "Spy"
+	ROLE_OBSERVER  = "Observer"
 	ROLE_OB_NORMAL = "ObNormal" // eliminated normal, kept for server-internal tracking
 	ROLE_OB_SPY    = "ObSpy"    // eliminated spy, kept for server-internal tracking
 	ROLE_OB_BLANK  = "ObBlank"  // eliminated blank, kept for server-internal tracking
+	ROLE_HIDDEN    = "Hidden"   // in-game participant whose role is not disclosed to the viewer
 )
 
 type Player struct {
@@ -19,6 +22,20 @@ type Player struct {
 	Role string `json:"role"`
 	Word string `json:"word,omitempty"`
 
+	// Disconnected 表示玩家断线，处于等待重连的宽限期内
+	Disconnected bool `json:"disconnected,omitempty"`
+	// Muted 表示玩家被管理员禁言，不能发言
+	Muted bool `json:"muted,omitempty"`
+	// Ready 表示玩家在等待阶段已准备
+	Ready bool `json:"ready,omitempty"`
+
+	// JoinedAt 记录首次加入房间的时间，用于选择最早加入的玩家
+	JoinedAt time.Time `json:"-"`
+	// DisconnectedAt 记录最近一次断线的时间
+	DisconnectedAt time.Time `json:"-"`
+	// RemoteIP 记录最近一次连接的客户端 IP，用于封禁
+	RemoteIP string `json:"-"`
+
 	// ReqCh  chan RequestWrapper
 	RespCh chan ResponseWrapper `json:"-"`
 }
@@ -0,0 +1,273 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// 一局游戏允许的玩家人数范围（不包括管理员和观察者）
+	SETTINGS_PLAYERS_LOWER = 4
+	SETTINGS_PLAYERS_UPPER = 20
+)
+
+// 加时赛（PK）后仍然平票时的处理策略
+const (
+	// 本轮无人出局
+	TIE_POLICY_NONE = "none"
+	// 从平票玩家中随机淘汰一人
+	TIE_POLICY_RANDOM = "random"
+	// 淘汰所有平票玩家
+	TIE_POLICY_ALL = "all"
+)
+
+// 发言中出现词语（泄露词语）时的处理策略
+const (
+	// 拒绝该条发言，发言者需要重新描述
+	LEAK_POLICY_REJECT = "reject"
+	// 将词语替换为 * 后照常广播
+	LEAK_POLICY_MASK = "mask"
+	// 淘汰发言者作为惩罚
+	LEAK_POLICY_ELIMINATE = "eliminate"
+)
+
+// RoomSettings 房间规则设置，创建房间时提供，等待阶段可由管理员修改
+type RoomSettings struct {
+	// 开始游戏所需的最少玩家数
+	MinPlayers int `json:"min_players"`
+	// 房间可容纳的最多玩家数，超出后加入者成为观察者
+	MaxPlayers int `json:"max_players"`
+	// 卧底人数
+	SpyCount int `json:"spy_count"`
+	// 白板人数
+	BlankCount int `json:"blank_count"`
+	// 最大轮数，超过后直接结算
+	MaxRounds int `json:"max_rounds"`
+	// 存活人数不超过该值且卧底方仍在场时，卧底方胜利
+	SpyWinThreshold int `json:"spy_win_threshold"`
+	// 淘汰所需的最少票数，最高票未达到时本轮无人出局；0 与 1 等价（零票永远不会被淘汰）
+	EliminateQuorum int `json:"eliminate_quorum"`
+	// 允许在投票截止前改票；开启后投票阶段持续到截止时间
+	AllowVoteChange bool `json:"allow_vote_change"`
+	// 不记名投票：投票过程中只广播投票进度，判定时一次性公开投票表
+	SecretBallot bool `json:"secret_ballot"`
+	// 加时赛后仍然平票时的处理策略，未提供时为随机淘汰
+	TiePolicy string `json:"tie_policy"`
+	// 发言泄露词语时的处理策略，未提供时为拒绝发言
+	LeakPolicy string `json:"leak_policy"`
+	// 盲主持模式：管理员作为普通玩家参与游戏，词语只能由服务端从词库随机抽取，管理员看不到其他玩家的词语
+	BlindHost bool `json:"blind_host"`
+	// 不知身份模式：平民和卧底只收到词语，不知道自己属于哪一方
+	UnawareRoles bool `json:"unaware_roles"`
+	// 不告知白板其身份，与 UnawareRoles 相互独立
+	HideBlankRole bool `json:"hide_blank_role"`
+	// 最终猜词：出局的卧底或白板有一次猜平民词的机会，猜中则卧底方获胜
+	FinalGuess bool `json:"final_guess"`
+	// 自动开始倒计时（秒）：等待阶段的在线玩家全部准备、人数足够且已设置词语时开始倒计时，0 表示不启用
+	AutoStartSeconds int `json:"auto_start_seconds"`
+	// 各阶段时长，未提供的字段使用服务器默认值
+	Timing TimingSettings `json:"timing"`
+}
+
+// DefaultRoomSettings 返回标准 8 人局设置：1 卧底、1 白板、最多 4 轮
+func DefaultRoomSettings() RoomSettings {
+	return RoomSettings{
+		MinPlayers:      8,
+		MaxPlayers:      8,
+		SpyCount:        1,
+		BlankCount:      1,
+		MaxRounds:       4,
+		SpyWinThreshold: 4,
+		EliminateQuorum: 1,
+		TiePolicy:       TIE_POLICY_RANDOM,
+		LeakPolicy:      LEAK_POLICY_REJECT,
+		Timing:          DefaultTimingSettings(),
+	}
+}
+
+// Validate 校验设置的取值范围以及各项之间的一致性
+func (rs RoomSettings) Validate() error {
+	if rs.MinPlayers < SETTINGS_PLAYERS_LOWER || rs.MinPlayers > SETTINGS_PLAYERS_UPPER {
+		return fmt.Errorf("最少玩家数必须在 %d 到 %d 之间", SETTINGS_PLAYERS_LOWER, SETTINGS_PLAYERS_UPPER)
+	}
+
+	if rs.MaxPlayers < rs.MinPlayers || rs.MaxPlayers > SETTINGS_PLAYERS_UPPER {
+		return fmt.Errorf("最多玩家数必须在最少玩家数到 %d 之间", SETTINGS_PLAYERS_UPPER)
+	}
+
+	if rs.SpyCount < 1 {
+		return errors.New("卧底人数至少为 1")
+	}
+
+	if rs.BlankCount < 0 {
+		return errors.New("白板人数不能为负数")
+	}
+
+	// 按最少人数开局时，平民人数必须多于卧底方人数
+	civilians := rs.MinPlayers - rs.SpyCount - rs.BlankCount
+	if civilians <= rs.SpyCount+rs.BlankCount {
+		return errors.New("平民人数必须多于卧底和白板人数之和")
+	}
+
+	if rs.MaxRounds < 1 {
+		return errors.New("最大轮数至少为 1")
+	}
+
+	if rs.SpyWinThreshold < 1 || rs.SpyWinThreshold >= rs.MinPlayers {
+		return errors.New("卧底胜利人数阈值必须至少为 1 且小于最少玩家数")
+	}
+
+	if rs.EliminateQuorum < 0 || rs.EliminateQuorum > rs.MinPlayers {
+		return errors.New("淘汰所需票数必须在 0 到最少玩家数之间")
+	}
+
+	switch rs.TiePolicy {
+	case TIE_POLICY_NONE, TIE_POLICY_RANDOM, TIE_POLICY_ALL:
+	default:
+		return fmt.Errorf("未知的平票处理策略：%s", rs.TiePolicy)
+	}
+
+	switch rs.LeakPolicy {
+	case LEAK_POLICY_REJECT, LEAK_POLICY_MASK, LEAK_POLICY_ELIMINATE:
+	default:
+		return fmt.Errorf("未知的泄露词语处理策略：%s", rs.LeakPolicy)
+	}
+
+	if rs.AutoStartSeconds != 0 && (rs.AutoStartSeconds < TIMING_STAGE_LOWER || rs.AutoStartSeconds > TIMING_STAGE_UPPER) {
+		return fmt.Errorf("自动开始倒计时必须为 0 或在 %d 到 %d 秒之间", TIMING_STAGE_LOWER, TIMING_STAGE_UPPER)
+	}
+
+	return rs.Timing.Validate()
+}
+
+// EffectiveQuorum 返回实际生效的淘汰票数，至少为 1
+func (rs RoomSettings) EffectiveQuorum() int {
+	return max(rs.EliminateQuorum, 1)
+}
+
+// WithDefaults 使用给定默认值填充未设置的时长和策略项，人数、轮数等规则项必须显式提供
+func (rs RoomSettings) WithDefaults(defaults RoomSettings) RoomSettings {
+	if rs.TiePolicy == "" {
+		rs.TiePolicy = defaults.TiePolicy
+	}
+
+	if rs.LeakPolicy == "" {
+		rs.LeakPolicy = defaults.LeakPolicy
+	}
+
+	rs.Timing = rs.Timing.WithDefaults(defaults.Timing)
+
+	return rs
+}
+
+const (
+	// 单个阶段时长的取值范围（秒）
+	TIMING_STAGE_LOWER = 3
+	TIMING_STAGE_UPPER = 600
+	// 全局游戏时长的取值范围（分钟）
+	TIMING_GAME_LOWER = 1
+	TIMING_GAME_UPPER = 180
+)
+
+// TimingSettings 各阶段时长设置，秒为单位，全局游戏时长以分钟为单位
+type TimingSettings struct {
+	PrepareSeconds    int `json:"prepare_seconds"`
+	FirstSpeakSeconds int `json:"first_speak_seconds"`
+	SpeakSeconds      int `json:"speak_seconds"`
+	VoteSeconds       int `json:"vote_seconds"`
+	JudgeSeconds      int `json:"judge_seconds"`
+	GuessSeconds      int `json:"guess_seconds"`
+	GameLimitMinutes  int `json:"game_limit_minutes"`
+}
+
+// DefaultTimingSettings 返回内置的默认时长，服务器配置可覆盖
+func DefaultTimingSettings() TimingSettings {
+	return TimingSettings{
+		PrepareSeconds:    30,
+		FirstSpeakSeconds: 40,
+		SpeakSeconds:      20,
+		VoteSeconds:       30,
+		JudgeSeconds:      10,
+		GuessSeconds:      30,
+		GameLimitMinutes:  30,
+	}
+}
+
+// WithDefaults 使用给定默认值填充未设置（<= 0）的字段
+func (ts TimingSettings) WithDefaults(defaults TimingSettings) TimingSettings {
+	fill := func(v *int, def int) {
+		if *v <= 0 {
+			*v = def
+		}
+	}
+
+	fill(&ts.PrepareSeconds, defaults.PrepareSeconds)
+	fill(&ts.FirstSpeakSeconds, defaults.FirstSpeakSeconds)
+	fill(&ts.SpeakSeconds, defaults.SpeakSeconds)
+	fill(&ts.VoteSeconds, defaults.VoteSeconds)
+	fill(&ts.JudgeSeconds, defaults.JudgeSeconds)
+	fill(&ts.GuessSeconds, defaults.GuessSeconds)
+	fill(&ts.GameLimitMinutes, defaults.GameLimitMinutes)
+
+	return ts
+}
+
+// Validate 校验各阶段时长的取值范围
+func (ts TimingSettings) Validate() error {
+	stages := []struct {
+		name    string
+		seconds int
+	}{
+		{"准备时长", ts.PrepareSeconds},
+		{"首位发言时长", ts.FirstSpeakSeconds},
+		{"发言时长", ts.SpeakSeconds},
+		{"投票时长", ts.VoteSeconds},
+		{"判定时长", ts.JudgeSeconds},
+		{"猜词时长", ts.GuessSeconds},
+	}
+
+	for _, stage := range stages {
+		if stage.seconds < TIMING_STAGE_LOWER || stage.seconds > TIMING_STAGE_UPPER {
+			return fmt.Errorf("%s必须在 %d 到 %d 秒之间", stage.name, TIMING_STAGE_LOWER, TIMING_STAGE_UPPER)
+		}
+	}
+
+	if ts.GameLimitMinutes < TIMING_GAME_LOWER || ts.GameLimitMinutes > TIMING_GAME_UPPER {
+		return fmt.Errorf("全局游戏时长必须在 %d 到 %d 分钟之间", TIMING_GAME_LOWER, TIMING_GAME_UPPER)
+	}
+
+	return nil
+}
+
+func (ts TimingSettings) Prepare() time.Duration {
+	return time.Duration(ts.PrepareSeconds) * time.Second
+}
+
+func (ts TimingSettings) FirstSpeak() time.Duration {
+	return time.Duration(ts.FirstSpeakSeconds) * time.Second
+}
+
+func (ts TimingSettings) Speak() time.Duration {
+	return time.Duration(ts.SpeakSeconds) * time.Second
+}
+
+func (ts TimingSettings) Vote() time.Duration {
+	return time.Duration(ts.VoteSeconds) * time.Second
+}
+
+func (ts TimingSettings) Judge() time.Duration {
+	return time.Duration(ts.JudgeSeconds) * time.Second
+}
+
+func (ts TimingSettings) Guess() time.Duration {
+	return time.Duration(ts.GuessSeconds) * time.Second
+}
+
+func (rs RoomSettings) AutoStart() time.Duration {
+	return time.Duration(rs.AutoStartSeconds) * time.Second
+}
+
+func (ts TimingSettings) GameLimit() time.Duration {
+	return time.Duration(ts.GameLimitMinutes) * time.Minute
+}
@@ -1,7 +1,13 @@
 package game
 
 import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -16,6 +22,51 @@ func GenID() string {
 	return id.String()[len(id.String())-8:]
 }
 
+// GenSecret 生成用于凭证的随机十六进制字符串
+func GenSecret() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		panic("Failed to generate secret: " + err.Error())
+	}
+
+	return hex.EncodeToString(buf)
+}
+
+// signReconnectToken 生成与房间和玩家绑定的重连凭证，格式为 <playerID>.<HMAC-SHA256 签名>
+func signReconnectToken(key []byte, roomID, playerID string) string {
+	return playerID + "." + hex.EncodeToString(reconnectMAC(key, roomID, playerID))
+}
+
+// parseReconnectToken 校验重连凭证的签名，返回凭证绑定的玩家 ID
+func parseReconnectToken(key []byte, roomID, token string) (string, bool) {
+	if len(key) == 0 {
+		return "", false
+	}
+
+	playerID, sigHex, found := strings.Cut(token, ".")
+	if !found || playerID == "" {
+		return "", false
+	}
+
+	sig, err := hex.DecodeString(sigHex)
+	if err != nil {
+		return "", false
+	}
+
+	if !hmac.Equal(sig, reconnectMAC(key, roomID, playerID)) {
+		return "", false
+	}
+
+	return playerID, true
+}
+
+func reconnectMAC(key []byte, roomID, playerID string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(roomID + "|" + playerID))
+
+	return mac.Sum(nil)
+}
+
 func mustMarshal(v any) json.RawMessage {
 	data, err := json.Marshal(v)
 	if err != nil {
@@ -26,17 +77,20 @@ func mustMarshal(v any) json.RawMessage {
 }
 
 // sanitizePlayer 创建玩家的公开视图副本，清除敏感信息（Word）
-// 注意：Role 字段保留，用于显示身份徽章（如 Admin/Observer）
+// 注意：Role 字段保留，用于显示身份徽章（如 Admin/Observer），游戏中的参与者身份统一显示为 Hidden
 func sanitizePlayer(p *Player) Player {
-	// 将内部的 Ob* 角色对外统一显示为 Observer
+	// 将内部的 Ob* 角色对外统一显示为 Observer，参与者身份不对外公开
 	role := toPublicRole(p.Role)
 
 	return Player{
-		ID:     p.ID,
-		Name:   p.Name,
-		Role:   role,
-		Word:   "", // 清空敏感字段
-		RespCh: nil,
+		ID:           p.ID,
+		Name:         p.Name,
+		Role:         role,
+		Word:         "", // 清空敏感字段
+		Disconnected: p.Disconnected,
+		Muted:        p.Muted,
+		Ready:        p.Ready,
+		RespCh:       nil,
 	}
 }
 
@@ -49,16 +103,41 @@ func buildPublicPlayersList(ctx *GameContext) []Player {
 	return players
 }
 
-// toPublicRole 将内部 Ob* 角色统一映射为 Observer，用于对外广播的状态同步
+// toPublicRole 将内部 Ob* 角色统一映射为 Observer、参与者身份映射为 Hidden，用于对外广播的状态同步
 func toPublicRole(role string) string {
 	switch role {
 	case ROLE_OB_NORMAL, ROLE_OB_SPY, ROLE_OB_BLANK:
 		return ROLE_OBSERVER
+	case ROLE_NORMAL, ROLE_SPY, ROLE_BLANK:
+		return ROLE_HIDDEN
 	default:
 		return role
 	}
 }
 
+// toPrivateRole 返回玩家本人和管理员视图中可见的身份：不知身份模式下平民和卧底显示为 Hidden，
+// 白板按 hide_blank_role 单独决定；被隐藏身份的玩家出局后显示为 Observer
+func toPrivateRole(settings RoomSettings, role string) string {
+	hidden := false
+
+	switch toOriginalRole(role) {
+	case ROLE_NORMAL, ROLE_SPY:
+		hidden = settings.UnawareRoles
+	case ROLE_BLANK:
+		hidden = settings.HideBlankRole
+	}
+
+	if !hidden {
+		return role
+	}
+
+	if isEliminatedRole(role) {
+		return ROLE_OBSERVER
+	}
+
+	return ROLE_HIDDEN
+}
+
 // toOriginalRole 将内部 Ob* 角色还原为被淘汰前的身份，用于 GameResult
 func toOriginalRole(role string) string {
 	switch role {
@@ -73,6 +152,42 @@ func toOriginalRole(role string) string {
 	}
 }
 
+// toEliminatedRole 将参与者的身份转换为内部 Ob* 角色，其余角色统一转换为 Observer
+func toEliminatedRole(role string) string {
+	switch role {
+	case ROLE_SPY:
+		return ROLE_OB_SPY
+	case ROLE_BLANK:
+		return ROLE_OB_BLANK
+	case ROLE_NORMAL:
+		return ROLE_OB_NORMAL
+	case ROLE_OB_NORMAL, ROLE_OB_SPY, ROLE_OB_BLANK:
+		return role
+	default:
+		return ROLE_OBSERVER
+	}
+}
+
+// isParticipant 标记仍在场的参与者角色
+func isParticipant(role string) bool {
+	switch role {
+	case ROLE_NORMAL, ROLE_SPY, ROLE_BLANK:
+		return true
+	default:
+		return false
+	}
+}
+
+// isEliminatedRole 标记被淘汰（或中途离开）的参与者角色
+func isEliminatedRole(role string) bool {
+	switch role {
+	case ROLE_OB_NORMAL, ROLE_OB_SPY, ROLE_OB_BLANK:
+		return true
+	default:
+		return false
+	}
+}
+
 // isObserverLike 标记所有观战/淘汰态的角色
 func isObserverLike(role string) bool {
 	switch role {
@@ -82,3 +197,12 @@ func isObserverLike(role string) bool {
 		return false
 	}
 }
+
+// latest 返回两个时间中较晚的一个
+func latest(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+
+	return b
+}
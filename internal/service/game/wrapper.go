@@ -2,19 +2,46 @@ package game
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 
 	"go.uber.org/zap"
 )
 
 // 请求类型
 const (
-	REQ_JOIN_GAME  = "JoinGame"
-	REQ_SET_WORDS  = "SetWords"
-	REQ_START_GAME = "StartGame"
-	REQ_DESCRIBE   = "Describe"
-	REQ_VOTE       = "Vote"
-	REQ_TIMEOUT    = "Timeout"
-	REQ_EXIT_GAME  = "ExitGame"
+	REQ_JOIN_GAME       = "JoinGame"
+	REQ_SET_WORDS       = "SetWords"
+	REQ_UPDATE_SETTINGS = "UpdateSettings"
+	REQ_START_GAME      = "StartGame"
+	REQ_DESCRIBE        = "Describe"
+	REQ_VOTE            = "Vote"
+	REQ_TIMEOUT         = "Timeout"
+	REQ_EXIT_GAME       = "ExitGame"
+
+	REQ_REMATCH         = "Rematch"
+	REQ_REMATCH_OPT_OUT = "RematchOptOut"
+
+	REQ_TRANSFER_ADMIN = "TransferAdmin"
+	REQ_SET_CO_HOST    = "SetCoHost"
+
+	REQ_KICK_PLAYER = "KickPlayer"
+	REQ_BAN_PLAYER  = "BanPlayer"
+	REQ_MUTE_PLAYER = "MutePlayer"
+
+	REQ_PAUSE_GAME  = "PauseGame"
+	REQ_RESUME_GAME = "ResumeGame"
+
+	REQ_SKIP_SPEAKER = "SkipSpeaker"
+	REQ_END_VOTING   = "EndVoting"
+	REQ_EXTEND_TIMER = "ExtendTimer"
+	REQ_ABORT_GAME   = "AbortGame"
+
+	REQ_SET_READY = "SetReady"
+
+	REQ_PICK_WORDS  = "PickWords"
+	REQ_SELECT_DECK = "SelectDeck"
+	REQ_GUESS_WORD  = "GuessWord"
 )
 
 type RequestWrapper struct {
@@ -22,6 +49,75 @@ type RequestWrapper struct {
 	Data    json.RawMessage `json:"data"`
 	// NativeData carries in-process payloads (e.g., channels) that cannot be JSON marshaled.
 	NativeData any `json:"-"`
+	// SenderID 是连接层认证过的玩家 ID，由 WebSocket 读协程写入；服务端内部产生的请求为空
+	SenderID string `json:"-"`
+}
+
+// 客户端请求中声明玩家身份的字段
+type playerClaims struct {
+	ReqPlayerID   string `json:"req_player_id"`
+	SetPlayerID   string `json:"set_player_id"`
+	StartPlayerID string `json:"start_player_id"`
+	VoterID       string `json:"voter_id"`
+	PlayerID      string `json:"player_id"`
+}
+
+// verifySender 校验客户端请求声明的玩家身份是否与连接绑定的身份一致，
+// 并拒绝只能由服务端内部产生的请求。校验通过后将身份字段统一填写为连接身份，客户端可以留空这些字段
+func verifySender(wrapper *RequestWrapper) error {
+	if wrapper.SenderID == "" {
+		return nil
+	}
+
+	switch wrapper.ReqType {
+	case REQ_TIMEOUT:
+		return errors.New("客户端不能发送 Timeout 请求")
+	case REQ_JOIN_GAME:
+		return errors.New("当前连接已加入房间，不能重复加入")
+	}
+
+	if len(wrapper.Data) == 0 {
+		return nil
+	}
+
+	var claims playerClaims
+	if err := json.Unmarshal(wrapper.Data, &claims); err != nil {
+		// 格式错误交给具体的解析函数处理
+		return nil
+	}
+
+	for _, claimed := range []string{
+		claims.ReqPlayerID,
+		claims.SetPlayerID,
+		claims.StartPlayerID,
+		claims.VoterID,
+		claims.PlayerID,
+	} {
+		if claimed != "" && claimed != wrapper.SenderID {
+			return fmt.Errorf("请求声明的玩家 %s 与连接身份不一致", claimed)
+		}
+	}
+
+	// 以连接绑定的身份为准：各请求只读取自己的身份字段，多余的字段在解析时被忽略
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(wrapper.Data, &fields); err != nil || fields == nil {
+		return nil
+	}
+
+	sender := mustMarshal(wrapper.SenderID)
+	for _, key := range []string{
+		"req_player_id",
+		"set_player_id",
+		"start_player_id",
+		"voter_id",
+		"player_id",
+	} {
+		fields[key] = sender
+	}
+
+	wrapper.Data = mustMarshal(fields)
+
+	return nil
 }
 
 func TryUnwrapJoinGameRequest(wrapper RequestWrapper) *JoinGameRequest {
@@ -70,6 +166,26 @@ func TryUnwrapSetWordsRequest(wrapper RequestWrapper) *SetWordsRequest {
 	return &setWordsRequest
 }
 
+func TryUnwrapUpdateSettingsRequest(wrapper RequestWrapper) *UpdateSettingsRequest {
+	if wrapper.ReqType != REQ_UPDATE_SETTINGS {
+		return nil
+	}
+
+	var updateSettingsRequest UpdateSettingsRequest
+
+	err := json.Unmarshal(wrapper.Data, &updateSettingsRequest)
+	if err != nil {
+		zap.L().Error(
+			"Failed to unwrap UpdateSettingsRequest",
+			zap.Error(err),
+			zap.Any("wrapper", wrapper),
+		)
+		return nil
+	}
+
+	return &updateSettingsRequest
+}
+
 func TryUnwrapStartGameRequest(wrapper RequestWrapper) *StartGameRequest {
 	if wrapper.ReqType != REQ_START_GAME {
 		return nil
@@ -176,19 +292,380 @@ func TryUnwrapExitGameRequest(wrapper RequestWrapper) *ExitGameRequest {
 	return &exitGameRequest
 }
 
+func TryUnwrapRematchRequest(wrapper RequestWrapper) *RematchRequest {
+	if wrapper.ReqType != REQ_REMATCH {
+		return nil
+	}
+
+	var rematchRequest RematchRequest
+
+	err := json.Unmarshal(wrapper.Data, &rematchRequest)
+	if err != nil {
+		zap.L().Error(
+			"Failed to unwrap RematchRequest",
+			zap.Error(err),
+			zap.Any("wrapper", wrapper),
+		)
+		return nil
+	}
+
+	return &rematchRequest
+}
+
+func TryUnwrapRematchOptOutRequest(wrapper RequestWrapper) *RematchOptOutRequest {
+	if wrapper.ReqType != REQ_REMATCH_OPT_OUT {
+		return nil
+	}
+
+	var rematchOptOutRequest RematchOptOutRequest
+
+	err := json.Unmarshal(wrapper.Data, &rematchOptOutRequest)
+	if err != nil {
+		zap.L().Error(
+			"Failed to unwrap RematchOptOutRequest",
+			zap.Error(err),
+			zap.Any("wrapper", wrapper),
+		)
+		return nil
+	}
+
+	return &rematchOptOutRequest
+}
+
+func TryUnwrapTransferAdminRequest(wrapper RequestWrapper) *TransferAdminRequest {
+	if wrapper.ReqType != REQ_TRANSFER_ADMIN {
+		return nil
+	}
+
+	var transferAdminRequest TransferAdminRequest
+
+	err := json.Unmarshal(wrapper.Data, &transferAdminRequest)
+	if err != nil {
+		zap.L().Error(
+			"Failed to unwrap TransferAdminRequest",
+			zap.Error(err),
+			zap.Any("wrapper", wrapper),
+		)
+		return nil
+	}
+
+	return &transferAdminRequest
+}
+
+func TryUnwrapSetCoHostRequest(wrapper RequestWrapper) *SetCoHostRequest {
+	if wrapper.ReqType != REQ_SET_CO_HOST {
+		return nil
+	}
+
+	var setCoHostRequest SetCoHostRequest
+
+	err := json.Unmarshal(wrapper.Data, &setCoHostRequest)
+	if err != nil {
+		zap.L().Error(
+			"Failed to unwrap SetCoHostRequest",
+			zap.Error(err),
+			zap.Any("wrapper", wrapper),
+		)
+		return nil
+	}
+
+	return &setCoHostRequest
+}
+
+func TryUnwrapKickPlayerRequest(wrapper RequestWrapper) *KickPlayerRequest {
+	if wrapper.ReqType != REQ_KICK_PLAYER {
+		return nil
+	}
+
+	var kickPlayerRequest KickPlayerRequest
+
+	err := json.Unmarshal(wrapper.Data, &kickPlayerRequest)
+	if err != nil {
+		zap.L().Error(
+			"Failed to unwrap KickPlayerRequest",
+			zap.Error(err),
+			zap.Any("wrapper", wrapper),
+		)
+		return nil
+	}
+
+	return &kickPlayerRequest
+}
+
+func TryUnwrapBanPlayerRequest(wrapper RequestWrapper) *BanPlayerRequest {
+	if wrapper.ReqType != REQ_BAN_PLAYER {
+		return nil
+	}
+
+	var banPlayerRequest BanPlayerRequest
+
+	err := json.Unmarshal(wrapper.Data, &banPlayerRequest)
+	if err != nil {
+		zap.L().Error(
+			"Failed to unwrap BanPlayerRequest",
+			zap.Error(err),
+			zap.Any("wrapper", wrapper),
+		)
+		return nil
+	}
+
+	return &banPlayerRequest
+}
+
+func TryUnwrapMutePlayerRequest(wrapper RequestWrapper) *MutePlayerRequest {
+	if wrapper.ReqType != REQ_MUTE_PLAYER {
+		return nil
+	}
+
+	var mutePlayerRequest MutePlayerRequest
+
+	err := json.Unmarshal(wrapper.Data, &mutePlayerRequest)
+	if err != nil {
+		zap.L().Error(
+			"Failed to unwrap MutePlayerRequest",
+			zap.Error(err),
+			zap.Any("wrapper", wrapper),
+		)
+		return nil
+	}
+
+	return &mutePlayerRequest
+}
+
+func TryUnwrapPauseGameRequest(wrapper RequestWrapper) *PauseGameRequest {
+	if wrapper.ReqType != REQ_PAUSE_GAME {
+		return nil
+	}
+
+	var pauseGameRequest PauseGameRequest
+
+	err := json.Unmarshal(wrapper.Data, &pauseGameRequest)
+	if err != nil {
+		zap.L().Error(
+			"Failed to unwrap PauseGameRequest",
+			zap.Error(err),
+			zap.Any("wrapper", wrapper),
+		)
+		return nil
+	}
+
+	return &pauseGameRequest
+}
+
+func TryUnwrapResumeGameRequest(wrapper RequestWrapper) *ResumeGameRequest {
+	if wrapper.ReqType != REQ_RESUME_GAME {
+		return nil
+	}
+
+	var resumeGameRequest ResumeGameRequest
+
+	err := json.Unmarshal(wrapper.Data, &resumeGameRequest)
+	if err != nil {
+		zap.L().Error(
+			"Failed to unwrap ResumeGameRequest",
+			zap.Error(err),
+			zap.Any("wrapper", wrapper),
+		)
+		return nil
+	}
+
+	return &resumeGameRequest
+}
+
+func TryUnwrapSkipSpeakerRequest(wrapper RequestWrapper) *SkipSpeakerRequest {
+	if wrapper.ReqType != REQ_SKIP_SPEAKER {
+		return nil
+	}
+
+	var skipSpeakerRequest SkipSpeakerRequest
+
+	err := json.Unmarshal(wrapper.Data, &skipSpeakerRequest)
+	if err != nil {
+		zap.L().Error(
+			"Failed to unwrap SkipSpeakerRequest",
+			zap.Error(err),
+			zap.Any("wrapper", wrapper),
+		)
+		return nil
+	}
+
+	return &skipSpeakerRequest
+}
+
+func TryUnwrapEndVotingRequest(wrapper RequestWrapper) *EndVotingRequest {
+	if wrapper.ReqType != REQ_END_VOTING {
+		return nil
+	}
+
+	var endVotingRequest EndVotingRequest
+
+	err := json.Unmarshal(wrapper.Data, &endVotingRequest)
+	if err != nil {
+		zap.L().Error(
+			"Failed to unwrap EndVotingRequest",
+			zap.Error(err),
+			zap.Any("wrapper", wrapper),
+		)
+		return nil
+	}
+
+	return &endVotingRequest
+}
+
+func TryUnwrapExtendTimerRequest(wrapper RequestWrapper) *ExtendTimerRequest {
+	if wrapper.ReqType != REQ_EXTEND_TIMER {
+		return nil
+	}
+
+	var extendTimerRequest ExtendTimerRequest
+
+	err := json.Unmarshal(wrapper.Data, &extendTimerRequest)
+	if err != nil {
+		zap.L().Error(
+			"Failed to unwrap ExtendTimerRequest",
+			zap.Error(err),
+			zap.Any("wrapper", wrapper),
+		)
+		return nil
+	}
+
+	return &extendTimerRequest
+}
+
+func TryUnwrapAbortGameRequest(wrapper RequestWrapper) *AbortGameRequest {
+	if wrapper.ReqType != REQ_ABORT_GAME {
+		return nil
+	}
+
+	var abortGameRequest AbortGameRequest
+
+	err := json.Unmarshal(wrapper.Data, &abortGameRequest)
+	if err != nil {
+		zap.L().Error(
+			"Failed to unwrap AbortGameRequest",
+			zap.Error(err),
+			zap.Any("wrapper", wrapper),
+		)
+		return nil
+	}
+
+	return &abortGameRequest
+}
+
+func TryUnwrapSetReadyRequest(wrapper RequestWrapper) *SetReadyRequest {
+	if wrapper.ReqType != REQ_SET_READY {
+		return nil
+	}
+
+	var setReadyRequest SetReadyRequest
+
+	err := json.Unmarshal(wrapper.Data, &setReadyRequest)
+	if err != nil {
+		zap.L().Error(
+			"Failed to unwrap SetReadyRequest",
+			zap.Error(err),
+			zap.Any("wrapper", wrapper),
+		)
+		return nil
+	}
+
+	return &setReadyRequest
+}
+
+func TryUnwrapPickWordsRequest(wrapper RequestWrapper) *PickWordsRequest {
+	if wrapper.ReqType != REQ_PICK_WORDS {
+		return nil
+	}
+
+	var pickWordsRequest PickWordsRequest
+
+	err := json.Unmarshal(wrapper.Data, &pickWordsRequest)
+	if err != nil {
+		zap.L().Error(
+			"Failed to unwrap PickWordsRequest",
+			zap.Error(err),
+			zap.Any("wrapper", wrapper),
+		)
+		return nil
+	}
+
+	return &pickWordsRequest
+}
+
+func TryUnwrapSelectDeckRequest(wrapper RequestWrapper) *SelectDeckRequest {
+	if wrapper.ReqType != REQ_SELECT_DECK {
+		return nil
+	}
+
+	var selectDeckRequest SelectDeckRequest
+
+	err := json.Unmarshal(wrapper.Data, &selectDeckRequest)
+	if err != nil {
+		zap.L().Error(
+			"Failed to unwrap SelectDeckRequest",
+			zap.Error(err),
+			zap.Any("wrapper", wrapper),
+		)
+		return nil
+	}
+
+	return &selectDeckRequest
+}
+
+func TryUnwrapGuessWordRequest(wrapper RequestWrapper) *GuessWordRequest {
+	if wrapper.ReqType != REQ_GUESS_WORD {
+		return nil
+	}
+
+	var guessWordRequest GuessWordRequest
+
+	err := json.Unmarshal(wrapper.Data, &guessWordRequest)
+	if err != nil {
+		zap.L().Error(
+			"Failed to unwrap GuessWordRequest",
+			zap.Error(err),
+			zap.Any("wrapper", wrapper),
+		)
+		return nil
+	}
+
+	return &guessWordRequest
+}
+
 // 响应类型
 const (
 	RESP_ERROR = "Error"
 
-	RESP_JOIN_GAME   = "JoinGame"
-	RESP_SET_WORDS   = "SetWords"
-	RESP_START_GAME  = "StartGame"
-	RESP_DESCRIBE    = "Describe"
-	RESP_VOTE        = "Vote"
-	RESP_GAME_STATE  = "GameState"
-	RESP_ELIMINATE   = "Eliminate"
-	RESP_GAME_RESULT = "GameResult"
-	RESP_EXIT_GAME   = "ExitGame"
+	RESP_JOIN_GAME       = "JoinGame"
+	RESP_SET_WORDS       = "SetWords"
+	RESP_UPDATE_SETTINGS = "UpdateSettings"
+	RESP_START_GAME      = "StartGame"
+	RESP_DESCRIBE        = "Describe"
+	RESP_VOTE            = "Vote"
+	RESP_VOTE_PROGRESS   = "VoteProgress"
+	RESP_VOTE_REVEAL     = "VoteReveal"
+	RESP_GAME_STATE      = "GameState"
+	RESP_ELIMINATE       = "Eliminate"
+	RESP_NO_ELIMINATION  = "NoElimination"
+	RESP_ROUND_SUMMARY   = "RoundSummary"
+	RESP_GAME_RESULT     = "GameResult"
+	RESP_EXIT_GAME       = "ExitGame"
+
+	RESP_PLAYER_DISCONNECTED = "PlayerDisconnected"
+	RESP_MODERATION          = "Moderation"
+	RESP_GAME_PAUSED         = "GamePaused"
+	RESP_ADMIN_OVERRIDE      = "AdminOverride"
+	RESP_SET_READY           = "SetReady"
+	RESP_AUTO_START          = "AutoStart"
+	RESP_PICK_WORDS          = "PickWords"
+	RESP_SELECT_DECK         = "SelectDeck"
+	RESP_WORD_LEAK           = "WordLeak"
+	RESP_GUESS_WORD          = "GuessWord"
+	RESP_GUESS_RESULT        = "GuessResult"
+
+	RESP_MASTER_CHANGED  = "MasterChanged"
+	RESP_REMATCH         = "Rematch"
+	RESP_REMATCH_OPT_OUT = "RematchOptOut"
 )
 
 type ResponseWrapper struct {
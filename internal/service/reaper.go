@@ -0,0 +1,112 @@
+package service
+
+import (
+	"time"
+
+	"who-is-spy-be/internal/config"
+
+	"go.uber.org/zap"
+)
+
+// 房间回收原因
+const (
+	// 状态机事件循环已退出（例如游戏结束）
+	EVICT_MACHINE_EXITED = "machine_exited"
+	// 创建后超过 TTL 仍无人加入
+	EVICT_EMPTY = "empty"
+	// 所有玩家断线超过宽限期
+	EVICT_ABANDONED = "abandoned"
+)
+
+// runReaper 周期性巡检所有房间，回收空房间和被遗弃的房间
+func (rs *RoomService) runReaper(cfg config.ReaperConfig) {
+	interval := time.Duration(cfg.IntervalSeconds) * time.Second
+	if interval <= 0 {
+		zap.L().Warn("房间回收器巡检间隔无效，回收器未启动")
+		return
+	}
+
+	emptyTTL := time.Duration(cfg.EmptyRoomTTLSeconds) * time.Second
+	idleGrace := time.Duration(cfg.IdleGraceSeconds) * time.Second
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		rs.reapOnce(now, emptyTTL, idleGrace)
+	}
+}
+
+// reapOnce 执行一次巡检，根据状态机发布的摘要判断房间是否需要回收
+func (rs *RoomService) reapOnce(now time.Time, emptyTTL, idleGrace time.Duration) {
+	evictReasons := make(map[string]string)
+
+	rs.mu.Lock()
+	for roomID, gameHnd := range rs.gameHndMap {
+		summary := gameHnd.machine.Summary()
+
+		if summary.PlayerCount == 0 {
+			if emptyTTL > 0 && now.Sub(summary.CreatedAt) > emptyTTL {
+				evictReasons[roomID] = EVICT_EMPTY
+			}
+			continue
+		}
+
+		if summary.OnlineCount == 0 &&
+			!summary.IdleSince.IsZero() &&
+			idleGrace > 0 &&
+			now.Sub(summary.IdleSince) > idleGrace {
+			evictReasons[roomID] = EVICT_ABANDONED
+		}
+	}
+	rs.mu.Unlock()
+
+	for roomID, reason := range evictReasons {
+		rs.evictRoom(roomID, reason)
+	}
+}
+
+// evictRoom 从房间表中移除房间并通知状态机退出，同时记录回收次数
+func (rs *RoomService) evictRoom(roomID string, reason string) {
+	rs.mu.Lock()
+
+	gameHnd, ok := rs.gameHndMap[roomID]
+	if !ok {
+		rs.mu.Unlock()
+		return
+	}
+
+	delete(rs.gameHndMap, roomID)
+
+	rs.evictions[reason]++
+	reasonCount := rs.evictions[reason]
+	remaining := len(rs.gameHndMap)
+
+	rs.mu.Unlock()
+
+	gameHnd.stop()
+
+	// 房间词库随房间一起回收
+	rs.decks.RemoveRoom(roomID)
+
+	zap.L().Info(
+		"回收房间",
+		zap.String("room_id", roomID),
+		zap.String("reason", reason),
+		zap.Int("reason_count", reasonCount),
+		zap.Int("remaining_rooms", remaining),
+	)
+}
+
+// EvictionStats 返回按原因统计的房间回收次数
+func (rs *RoomService) EvictionStats() map[string]int {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	stats := make(map[string]int, len(rs.evictions))
+	for reason, count := range rs.evictions {
+		stats[reason] = count
+	}
+
+	return stats
+}
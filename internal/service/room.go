@@ -2,30 +2,87 @@ package service
 
 import (
 	"errors"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
+	"who-is-spy-be/internal/config"
 	"who-is-spy-be/internal/service/game"
+	"who-is-spy-be/internal/service/wordbank"
 
 	"go.uber.org/zap"
 )
 
 type RoomService struct {
+	cfg *config.AppConfig
+
 	mu         sync.Mutex
 	gameHndMap map[string]*gameHandle
+	// 按回收原因统计的房间回收次数
+	evictions map[string]int
+	// 签发重连凭证的密钥
+	reconnectKey []byte
+	// 内置词库，所有房间共享
+	wordBank *wordbank.Bank
+	// 上传的全服词库和房间词库
+	decks *wordbank.Store
 }
 
-func NewRoomService() *RoomService {
+func NewRoomService(cfg *config.AppConfig) *RoomService {
 	gameHndMap := make(map[string]*gameHandle)
 
-	return &RoomService{
-		gameHndMap: gameHndMap,
+	// 未配置密钥时使用随机密钥，服务重启后旧凭证失效（房间本身也不会保留）
+	reconnectKey := []byte(cfg.Room.ReconnectSecret)
+	if len(reconnectKey) == 0 {
+		reconnectKey = []byte(game.GenSecret())
+	}
+
+	// 词库加载失败不影响启动，管理员仍可以手动设置词语
+	wordBank, err := wordbank.Load(cfg.WordBank.Path)
+	if err != nil {
+		zap.L().Warn(
+			"加载词库失败，内置词库不可用",
+			zap.String("path", cfg.WordBank.Path),
+			zap.Error(err),
+		)
+	} else {
+		zap.L().Info(
+			"已加载词库",
+			zap.String("path", cfg.WordBank.Path),
+			zap.Int("pairs", wordBank.Len()),
+		)
+	}
+
+	rs := &RoomService{
+		cfg:          cfg,
+		gameHndMap:   gameHndMap,
+		evictions:    make(map[string]int),
+		reconnectKey: reconnectKey,
+		wordBank:     wordBank,
+		decks:        wordbank.NewStore(wordBank),
 	}
+
+	// 启动房间回收器
+	go rs.runReaper(cfg.Reaper)
+
+	return rs
 }
 
 type gameHandle struct {
-	reqCh  chan game.RequestWrapper
-	doneCh chan struct{}
+	machine  *game.GameMachine
+	reqCh    chan game.RequestWrapper
+	doneCh   chan struct{}
+	stopOnce sync.Once
+	// 房主凭证，上传房间词库时校验
+	creatorToken string
+}
+
+// stop 通知状态机退出事件循环，可重复调用
+func (gh *gameHandle) stop() {
+	gh.stopOnce.Do(func() {
+		close(gh.doneCh)
+	})
 }
 
 func (rs *RoomService) CreateRoom(
@@ -38,13 +95,45 @@ func (rs *RoomService) CreateRoom(
 		return nil, errors.New("房间名称不能为空")
 	}
 
+	// 默认设置中的时长取自服务器配置
+	defaults := game.DefaultRoomSettings()
+	defaults.Timing = rs.defaultTiming()
+
+	settings := defaults
+	if args.Settings != nil {
+		// 未提供的时长和策略项使用默认值
+		settings = args.Settings.WithDefaults(defaults)
+	}
+
+	if err := settings.Validate(); err != nil {
+		return nil, err
+	}
+
 	// Generate room ID using the last 8 characters of the UUID
 	roomID := game.GenID()[len(game.GenID())-8:]
 
+	// 生成房主凭证，只返回给创建者
+	creatorToken := game.GenSecret()
+
 	// 创建房间对应的游戏状态机
 	doneCh := make(chan struct{}, 64)
 
-	gm := game.NewGameMachine(roomID, doneCh)
+	gm := game.NewGameMachine(
+		game.RoomMeta{
+			RoomID:       roomID,
+			RoomName:     args.RoomName,
+			CreatorName:  args.CreatorName,
+			Settings:     settings,
+			CreatorToken: creatorToken,
+			CreatorGrace: time.Duration(rs.cfg.Room.CreatorGraceSeconds) * time.Second,
+			ReconnectKey: rs.reconnectKey,
+			WordBank:     rs.wordBank,
+			Decks:        rs.decks,
+
+			DisconnectGrace: time.Duration(rs.cfg.Room.DisconnectGraceSeconds) * time.Second,
+		},
+		doneCh,
+	)
 
 	// 将游戏状态机保存到 map 中
 	if gm.GetReqCh() == nil {
@@ -58,8 +147,10 @@ func (rs *RoomService) CreateRoom(
 	rs.mu.Lock()
 
 	rs.gameHndMap[roomID] = &gameHandle{
-		reqCh:  gm.GetReqCh(),
-		doneCh: doneCh,
+		machine:      gm,
+		reqCh:        gm.GetReqCh(),
+		doneCh:       doneCh,
+		creatorToken: creatorToken,
 	}
 
 	// 释放协程，启动游戏状态机的事件循环
@@ -75,13 +166,117 @@ func (rs *RoomService) CreateRoom(
 			"游戏状态机协程已退出",
 			zap.String("room_id", roomID),
 		)
+
+		// 状态机退出后回收房间（若已被回收器移除则忽略）
+		rs.evictRoom(roomID, EVICT_MACHINE_EXITED)
 	}()
 
 	rs.mu.Unlock()
 
 	// 返回创建成功的响应
 	resp := &game.CreateRoomResponse{
-		RoomID: roomID,
+		RoomID:       roomID,
+		CreatorToken: creatorToken,
+	}
+
+	return resp, nil
+}
+
+// defaultTiming 返回服务器配置的默认时长，未配置的字段使用内置默认值
+func (rs *RoomService) defaultTiming() game.TimingSettings {
+	timingCfg := rs.cfg.Room.Timing
+
+	configured := game.TimingSettings{
+		PrepareSeconds:    timingCfg.PrepareSeconds,
+		FirstSpeakSeconds: timingCfg.FirstSpeakSeconds,
+		SpeakSeconds:      timingCfg.SpeakSeconds,
+		VoteSeconds:       timingCfg.VoteSeconds,
+		JudgeSeconds:      timingCfg.JudgeSeconds,
+		GuessSeconds:      timingCfg.GuessSeconds,
+		GameLimitMinutes:  timingCfg.GameLimitMinutes,
+	}
+
+	return configured.WithDefaults(game.DefaultTimingSettings())
+}
+
+const (
+	// 房间列表默认每页数量
+	DEFAULT_ROOM_PAGE_SIZE = 20
+	// 房间列表每页数量上限
+	MAX_ROOM_PAGE_SIZE = 100
+)
+
+// ListRooms 返回大厅房间列表，数据来自各状态机发布的摘要，不会与事件循环竞争
+func (rs *RoomService) ListRooms(
+	args game.ListRoomsRequest,
+) (
+	*game.ListRoomsResponse,
+	error,
+) {
+	if args.Page <= 0 {
+		args.Page = 1
+	}
+
+	if args.PageSize <= 0 {
+		args.PageSize = DEFAULT_ROOM_PAGE_SIZE
+	}
+
+	if args.PageSize > MAX_ROOM_PAGE_SIZE {
+		return nil, errors.New("每页数量不能超过 100")
+	}
+
+	if args.Stage != "" && !game.IsValidStage(args.Stage) {
+		return nil, errors.New("未知的游戏阶段")
+	}
+
+	keyword := strings.ToLower(strings.TrimSpace(args.Name))
+
+	rs.mu.Lock()
+	summaries := make([]game.RoomSummary, 0, len(rs.gameHndMap))
+	for _, gameHnd := range rs.gameHndMap {
+		summaries = append(summaries, gameHnd.machine.Summary())
+	}
+	rs.mu.Unlock()
+
+	// 过滤房间名称和阶段
+	rooms := make([]game.RoomSummary, 0, len(summaries))
+	for _, summary := range summaries {
+		if keyword != "" && !strings.Contains(strings.ToLower(summary.RoomName), keyword) {
+			continue
+		}
+
+		if args.Stage != "" && summary.Stage != args.Stage {
+			continue
+		}
+
+		rooms = append(rooms, summary)
+	}
+
+	// 按创建时间倒序，保证分页稳定
+	sort.Slice(rooms, func(i, j int) bool {
+		if rooms[i].CreatedAt.Equal(rooms[j].CreatedAt) {
+			return rooms[i].RoomID < rooms[j].RoomID
+		}
+		return rooms[i].CreatedAt.After(rooms[j].CreatedAt)
+	})
+
+	total := len(rooms)
+
+	start := (args.Page - 1) * args.PageSize
+	if start > total {
+		start = total
+	}
+
+	end := start + args.PageSize
+	if end > total {
+		end = total
+	}
+
+	resp := &game.ListRoomsResponse{
+		Rooms:    rooms[start:end],
+		Total:    total,
+		Page:     args.Page,
+		PageSize: args.PageSize,
 	}
 
 	return resp, nil
@@ -96,18 +291,23 @@ func (rs *RoomService) JoinRoom(
 		return nil, errors.New("房间 ID 和加入者名称不能为空")
 	}
 
+	rs.mu.Lock()
 	gameHnd, ok := rs.gameHndMap[args.RoomID]
+	rs.mu.Unlock()
+
 	if !ok {
 		return nil, errors.New("房间不存在")
 	}
 
-	// 构造加入请求，保留客户端可能提供的 PlayerID/Observer 字段
+	// 构造加入请求，保留客户端可能提供的 ReconnectToken/Observer/CreatorToken 字段
 	req := game.JoinGameRequest{
-		RoomID:     args.RoomID,
-		JoinerName: args.JoinerName,
-		PlayerID:   args.PlayerID,
-		Observer:   args.Observer,
-		RespCh:     respCh,
+		RoomID:         args.RoomID,
+		JoinerName:     args.JoinerName,
+		ReconnectToken: args.ReconnectToken,
+		Observer:       args.Observer,
+		CreatorToken:   args.CreatorToken,
+		RespCh:         respCh,
+		RemoteIP:       args.RemoteIP,
 	}
 
 	// 直接传递 native payload，保留 RespCh 引用，避免 JSON 丢失通道信息。
@@ -0,0 +1,140 @@
+package wordbank
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// 词库文件格式
+const (
+	FORMAT_JSON = "json"
+	FORMAT_YAML = "yaml"
+	FORMAT_CSV  = "csv"
+)
+
+// CSV 的列顺序，前两列必填；第一行为表头时跳过
+var csvHeader = []string{"civilian", "spy", "category", "difficulty", "language"}
+
+// JSON/YAML 文件格式，字段名相同；JSON 也接受直接以词对数组作为顶层
+type bankFile struct {
+	Pairs []Pair `json:"pairs" yaml:"pairs"`
+}
+
+// Decode 按指定格式解析词对，不做内容校验（由 New 负责）
+func Decode(data []byte, format string) ([]Pair, error) {
+	switch format {
+	case FORMAT_JSON:
+		trimmed := bytes.TrimSpace(data)
+		if len(trimmed) > 0 && trimmed[0] == '[' {
+			var pairs []Pair
+			if err := json.Unmarshal(trimmed, &pairs); err != nil {
+				return nil, err
+			}
+			return pairs, nil
+		}
+
+		var file bankFile
+		if err := json.Unmarshal(data, &file); err != nil {
+			return nil, err
+		}
+		return file.Pairs, nil
+
+	case FORMAT_YAML:
+		var file bankFile
+		if err := yaml.Unmarshal(data, &file); err != nil {
+			return nil, err
+		}
+		return file.Pairs, nil
+
+	case FORMAT_CSV:
+		return decodeCSV(data)
+
+	default:
+		return nil, fmt.Errorf("不支持的词库格式：%s", format)
+	}
+}
+
+func decodeCSV(data []byte) ([]Pair, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	pairs := make([]Pair, 0)
+
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if line == 1 && len(record) > 0 && strings.EqualFold(strings.TrimSpace(strings.TrimPrefix(record[0], "\ufeff")), csvHeader[0]) {
+			continue
+		}
+
+		if len(record) < 2 {
+			return nil, fmt.Errorf("第 %d 行至少需要平民词和卧底词两列", line)
+		}
+
+		field := func(i int) string {
+			if i < len(record) {
+				return record[i]
+			}
+			return ""
+		}
+
+		pairs = append(pairs, Pair{
+			Civilian:   field(0),
+			Spy:        field(1),
+			Category:   field(2),
+			Difficulty: field(3),
+			Language:   field(4),
+		})
+	}
+
+	return pairs, nil
+}
+
+// Encode 按指定格式导出词对，导出结果可以直接重新导入
+func Encode(pairs []Pair, format string) ([]byte, error) {
+	switch format {
+	case FORMAT_JSON:
+		return json.MarshalIndent(bankFile{Pairs: pairs}, "", "  ")
+
+	case FORMAT_YAML:
+		return yaml.Marshal(bankFile{Pairs: pairs})
+
+	case FORMAT_CSV:
+		var buf bytes.Buffer
+
+		writer := csv.NewWriter(&buf)
+		if err := writer.Write(csvHeader); err != nil {
+			return nil, err
+		}
+
+		for _, p := range pairs {
+			if err := writer.Write([]string{p.Civilian, p.Spy, p.Category, p.Difficulty, p.Language}); err != nil {
+				return nil, err
+			}
+		}
+
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return nil, err
+		}
+
+		return buf.Bytes(), nil
+
+	default:
+		return nil, fmt.Errorf("不支持的词库格式：%s", format)
+	}
+}
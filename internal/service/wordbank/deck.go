@@ -0,0 +1,156 @@
+package wordbank
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// 内置词库作为全服词库的固定 ID
+	BUILTIN_DECK_ID = "builtin"
+	// 单个词库的词对数量上限
+	MAX_DECK_PAIRS = 2000
+	// 全服词库和每个房间的词库数量上限
+	MAX_DECKS_PER_SCOPE = 50
+)
+
+var ErrDeckNotFound = errors.New("词库不存在")
+
+// Deck 一个命名的词库，RoomID 为空表示全服共享，否则只对该房间可见
+type Deck struct {
+	ID        string
+	Name      string
+	RoomID    string
+	CreatedAt time.Time
+	Bank      *Bank
+}
+
+// DeckInfo 词库列表中展示的摘要，不包含词语
+type DeckInfo struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	RoomID     string    `json:"room_id,omitempty"`
+	PairCount  int       `json:"pair_count"`
+	Categories []string  `json:"categories"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (d *Deck) Info() DeckInfo {
+	return DeckInfo{
+		ID:         d.ID,
+		Name:       d.Name,
+		RoomID:     d.RoomID,
+		PairCount:  d.Bank.Len(),
+		Categories: d.Bank.Categories(),
+		CreatedAt:  d.CreatedAt,
+	}
+}
+
+// Store 保存全服词库和房间词库，可在多个协程中并发使用
+type Store struct {
+	mu    sync.RWMutex
+	decks map[string]*Deck
+}
+
+// NewStore 创建词库仓库，builtin 不为空时注册为内置的全服词库
+func NewStore(builtin *Bank) *Store {
+	store := &Store{decks: make(map[string]*Deck)}
+
+	if builtin != nil {
+		store.decks[BUILTIN_DECK_ID] = &Deck{
+			ID:        BUILTIN_DECK_ID,
+			Name:      "内置词库",
+			CreatedAt: time.Now(),
+			Bank:      builtin,
+		}
+	}
+
+	return store
+}
+
+// Add 保存新的词库，roomID 为空时为全服词库
+func (s *Store) Add(name string, roomID string, bank *Bank) (*Deck, error) {
+	if bank.Len() == 0 {
+		return nil, errors.New("词库不能为空")
+	}
+
+	if bank.Len() > MAX_DECK_PAIRS {
+		return nil, errors.New("词库的词对数量超过上限")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := 0
+	for _, d := range s.decks {
+		if d.RoomID == roomID {
+			count++
+		}
+	}
+
+	if count >= MAX_DECKS_PER_SCOPE {
+		return nil, errors.New("词库数量已达上限")
+	}
+
+	deck := &Deck{
+		ID:        uuid.NewString(),
+		Name:      name,
+		RoomID:    roomID,
+		CreatedAt: time.Now(),
+		Bank:      bank,
+	}
+
+	s.decks[deck.ID] = deck
+
+	return deck, nil
+}
+
+// Get 返回房间可以使用的词库：全服词库或属于该房间的词库
+func (s *Store) Get(deckID string, roomID string) (*Deck, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	deck, ok := s.decks[deckID]
+	if !ok || (deck.RoomID != "" && deck.RoomID != roomID) {
+		return nil, ErrDeckNotFound
+	}
+
+	return deck, nil
+}
+
+// List 返回全服词库，以及 roomID 不为空时该房间的词库，按创建时间排序
+func (s *Store) List(roomID string) []DeckInfo {
+	s.mu.RLock()
+	infos := make([]DeckInfo, 0, len(s.decks))
+	for _, d := range s.decks {
+		if d.RoomID == "" || (roomID != "" && d.RoomID == roomID) {
+			infos = append(infos, d.Info())
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].CreatedAt.Equal(infos[j].CreatedAt) {
+			return infos[i].ID < infos[j].ID
+		}
+		return infos[i].CreatedAt.Before(infos[j].CreatedAt)
+	})
+
+	return infos
+}
+
+// RemoveRoom 删除房间的所有词库，房间回收时调用
+func (s *Store) RemoveRoom(roomID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, d := range s.decks {
+		if d.RoomID == roomID {
+			delete(s.decks, id)
+		}
+	}
+}
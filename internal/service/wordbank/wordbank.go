@@ -0,0 +1,195 @@
+package wordbank
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"who-is-spy-be/internal/service/wordmatch"
+)
+
+var (
+	// 没有符合筛选条件的词对
+	ErrNoMatch = errors.New("没有符合条件的词对")
+	// 符合筛选条件的词对都已经玩过
+	ErrExhausted = errors.New("符合条件的词对都已玩过")
+)
+
+// Pair 一组平民词和卧底词，以及用于筛选的分类、难度和语言标签
+type Pair struct {
+	Civilian   string `json:"civilian" yaml:"civilian"`
+	Spy        string `json:"spy" yaml:"spy"`
+	Category   string `json:"category" yaml:"category"`
+	Difficulty string `json:"difficulty" yaml:"difficulty"`
+	Language   string `json:"language" yaml:"language"`
+}
+
+// Key 返回词对的唯一标识，用于记录房间已经玩过的词对
+func (p Pair) Key() string {
+	return PairKey(p.Civilian, p.Spy)
+}
+
+// PairKey 根据平民词和卧底词生成词对标识
+func PairKey(civilian string, spy string) string {
+	return civilian + "|" + spy
+}
+
+// Filter 词对筛选条件，为空的字段不参与筛选，比较时忽略大小写
+type Filter struct {
+	Category   string `json:"category"`
+	Difficulty string `json:"difficulty"`
+	Language   string `json:"language"`
+}
+
+func (f Filter) Match(p Pair) bool {
+	matchTag := func(want, got string) bool {
+		return want == "" || strings.EqualFold(want, got)
+	}
+
+	return matchTag(f.Category, p.Category) &&
+		matchTag(f.Difficulty, p.Difficulty) &&
+		matchTag(f.Language, p.Language)
+}
+
+// Bank 词库，加载后只读，可以在多个房间之间共享
+type Bank struct {
+	pairs []Pair
+}
+
+// New 校验词对后构造词库：词语不能为空，平民词和卧底词不能相同，词对不能重复（交换顺序也视为重复）。
+// 比较时使用与泄词检测相同的规范化规则，大小写、全角半角和繁简体不同的词语视为相同
+func New(pairs []Pair) (*Bank, error) {
+	seen := make(map[string]int, len(pairs))
+	bank := &Bank{pairs: make([]Pair, 0, len(pairs))}
+
+	for i, p := range pairs {
+		p.Civilian = strings.TrimSpace(p.Civilian)
+		p.Spy = strings.TrimSpace(p.Spy)
+		p.Category = strings.TrimSpace(p.Category)
+		p.Difficulty = strings.TrimSpace(p.Difficulty)
+		p.Language = strings.TrimSpace(p.Language)
+
+		if p.Civilian == "" || p.Spy == "" {
+			return nil, fmt.Errorf("第 %d 个词对的平民词和卧底词不能为空", i+1)
+		}
+
+		civilian := wordmatch.Normalize(p.Civilian)
+		spy := wordmatch.Normalize(p.Spy)
+
+		if civilian == "" || spy == "" {
+			return nil, fmt.Errorf("第 %d 个词对的平民词和卧底词不能只包含空白和标点", i+1)
+		}
+
+		if civilian == spy {
+			return nil, fmt.Errorf("第 %d 个词对的平民词和卧底词不能相同", i+1)
+		}
+
+		if first, ok := seen[PairKey(civilian, spy)]; ok {
+			return nil, fmt.Errorf("第 %d 个词对与第 %d 个词对重复", i+1, first)
+		}
+
+		if first, ok := seen[PairKey(spy, civilian)]; ok {
+			return nil, fmt.Errorf("第 %d 个词对与第 %d 个词对重复", i+1, first)
+		}
+
+		seen[PairKey(civilian, spy)] = i + 1
+
+		bank.pairs = append(bank.pairs, p)
+	}
+
+	return bank, nil
+}
+
+// Load 从本地文件加载词库，按扩展名识别 YAML（.yaml/.yml）和 CSV，其余按 JSON 解析
+func Load(path string) (*Bank, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取词库文件失败: %w", err)
+	}
+
+	format := FORMAT_JSON
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		format = FORMAT_YAML
+	case ".csv":
+		format = FORMAT_CSV
+	}
+
+	pairs, err := Decode(data, format)
+	if err != nil {
+		return nil, fmt.Errorf("解析词库文件失败: %w", err)
+	}
+
+	return New(pairs)
+}
+
+// Pairs 返回词库中所有词对的副本
+func (b *Bank) Pairs() []Pair {
+	if b == nil {
+		return []Pair{}
+	}
+
+	return slices.Clone(b.pairs)
+}
+
+// Categories 返回词库中出现过的分类，按首次出现的顺序排列
+func (b *Bank) Categories() []string {
+	categories := make([]string, 0)
+	if b == nil {
+		return categories
+	}
+
+	for _, p := range b.pairs {
+		if p.Category != "" && !slices.Contains(categories, p.Category) {
+			categories = append(categories, p.Category)
+		}
+	}
+
+	return categories
+}
+
+// Len 返回词库中的词对数量
+func (b *Bank) Len() int {
+	if b == nil {
+		return 0
+	}
+
+	return len(b.pairs)
+}
+
+// Pick 从符合条件且不在 played 中的词对里随机选择一组
+func (b *Bank) Pick(filter Filter, played map[string]bool) (Pair, error) {
+	if b == nil {
+		return Pair{}, ErrNoMatch
+	}
+
+	matched := 0
+	candidates := make([]Pair, 0)
+
+	for _, p := range b.pairs {
+		if !filter.Match(p) {
+			continue
+		}
+		matched++
+
+		if played[p.Key()] {
+			continue
+		}
+
+		candidates = append(candidates, p)
+	}
+
+	if matched == 0 {
+		return Pair{}, ErrNoMatch
+	}
+
+	if len(candidates) == 0 {
+		return Pair{}, ErrExhausted
+	}
+
+	return candidates[rand.IntN(len(candidates))], nil
+}
@@ -0,0 +1,278 @@
+// Package wordmatch 检查文本中是否出现指定词语，用于发现玩家在发言中泄露词语
+//
+// 比较前统一规范化：忽略大小写、全角半角、空白和标点，繁体字转换为简体字；
+// 包含汉字的多字词语还会匹配其拼音拼写（忽略声调，ü 可写作 v 或 u）。
+package wordmatch
+
+import (
+	_ "embed"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/mozillazg/go-pinyin"
+	"golang.org/x/text/unicode/norm"
+)
+
+// 单个词语最多展开的拼音组合数（多音字会产生多种读法）
+const maxPinyinVariants = 16
+
+//go:embed ts_characters.txt
+var tsCharactersData string
+
+// 繁体字到简体字的映射
+var tsCharacters = parseTSCharacters(tsCharactersData)
+
+func parseTSCharacters(data string) map[rune]rune {
+	m := make(map[rune]rune)
+
+	for line := range strings.Lines(data) {
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		trad, simp, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+
+		t, s := []rune(trad), []rune(simp)
+		if len(t) == 1 && len(s) == 1 {
+			m[t[0]] = s[0]
+		}
+	}
+
+	return m
+}
+
+// text 规范化后的文本，记录每个字符在原文中的位置，便于遮挡原文
+type text struct {
+	runes []rune
+	// 规范化字符对应的原文字符下标
+	pos []int
+	// 规范化字符之前是否有被忽略的空白或标点，用于切分拼音
+	sep []bool
+}
+
+func normalize(s string) text {
+	var t text
+	sep := false
+
+	for i, r := range []rune(s) {
+		kept := false
+
+		// NFKD 分解全角字符和带声调的字母，随后丢弃组合符号
+		for _, d := range norm.NFKD.String(string(r)) {
+			if unicode.Is(unicode.Mn, d) {
+				continue
+			}
+
+			if unicode.IsSpace(d) || unicode.IsPunct(d) || unicode.IsSymbol(d) || unicode.IsControl(d) {
+				sep = true
+				continue
+			}
+
+			d = unicode.ToLower(d)
+			if simp, ok := tsCharacters[d]; ok {
+				d = simp
+			}
+
+			t.runes = append(t.runes, d)
+			t.pos = append(t.pos, i)
+			t.sep = append(t.sep, sep)
+			sep = false
+			kept = true
+		}
+
+		// 整个字符都被丢弃时（例如单独的组合符号）视为分隔
+		if !kept && !unicode.Is(unicode.Mn, r) {
+			sep = true
+		}
+	}
+
+	return t
+}
+
+// wordStart 判断第 i 个字符是否为一个拉丁字母单词的开头
+func (t text) wordStart(i int) bool {
+	return i == 0 || t.sep[i] || !isLetter(t.runes[i]) || !isLetter(t.runes[i-1])
+}
+
+func isLetter(r rune) bool {
+	return r >= 'a' && r <= 'z'
+}
+
+// Normalize 返回规范化后的文本，可用于比较两个词语是否相同
+func Normalize(s string) string {
+	return string(normalize(s).runes)
+}
+
+// Equal 判断两个词语规范化后是否相同，不比较拼音
+func Equal(a string, b string) bool {
+	na := Normalize(a)
+
+	return na != "" && na == Normalize(b)
+}
+
+// span 原文中需要遮挡的字符范围（包含两端）
+type span struct {
+	start int
+	end   int
+}
+
+// Find 返回 message 中出现的词语，按 words 的顺序排列，空词语会被忽略
+func Find(message string, words ...string) []string {
+	msg := normalize(message)
+	found := make([]string, 0)
+
+	for _, word := range words {
+		if len(match(msg, word)) > 0 && !slices.Contains(found, word) {
+			found = append(found, word)
+		}
+	}
+
+	return found
+}
+
+// Mask 将 message 中出现的词语（包括拼音拼写）替换为等长的 *
+func Mask(message string, words ...string) string {
+	msg := normalize(message)
+	runes := []rune(message)
+
+	for _, word := range words {
+		for _, sp := range match(msg, word) {
+			for i := sp.start; i <= sp.end; i++ {
+				runes[i] = '*'
+			}
+		}
+	}
+
+	return string(runes)
+}
+
+// match 返回词语在原文中出现的所有范围
+func match(msg text, word string) []span {
+	w := normalize(word)
+	if len(w.runes) == 0 {
+		return nil
+	}
+
+	spans := make([]span, 0)
+	n := len(w.runes)
+
+	// 直接匹配规范化后的字符；词语以字母开头或结尾时要求该侧是单词边界，避免 cat 匹配到 education
+	for i := 0; i+n <= len(msg.runes); i++ {
+		if !slices.Equal(msg.runes[i:i+n], w.runes) {
+			continue
+		}
+
+		if isLetter(w.runes[0]) && !msg.wordStart(i) {
+			continue
+		}
+
+		if isLetter(w.runes[n-1]) && i+n < len(msg.runes) && !msg.wordStart(i+n) {
+			continue
+		}
+
+		spans = append(spans, span{msg.pos[i], msg.pos[i+n-1]})
+	}
+
+	return append(spans, matchPinyin(msg, w)...)
+}
+
+// matchPinyin 在原文的拉丁字母中查找词语的拼音拼写
+func matchPinyin(msg text, w text) []span {
+	// 单音节的拼音太短，常常是其他词语的一部分（例如 mao zedong 中的 mao），不按拼音匹配
+	if len(w.runes) == 1 {
+		return nil
+	}
+
+	variants := pinyinVariants(w.runes)
+	if len(variants) == 0 {
+		return nil
+	}
+
+	// 抽出原文中的拉丁字母，记录每个字母是否为一个单词的开头
+	letters := make([]rune, 0, len(msg.runes))
+	index := make([]int, 0, len(msg.runes))
+	starts := make([]bool, 0, len(msg.runes))
+
+	for i, r := range msg.runes {
+		if !isLetter(r) {
+			continue
+		}
+
+		letters = append(letters, r)
+		index = append(index, i)
+		starts = append(starts, msg.wordStart(i))
+	}
+
+	spans := make([]span, 0)
+
+	for _, variant := range variants {
+		py := []rune(variant)
+
+		for i := 0; i+len(py) <= len(letters); i++ {
+			if !slices.Equal(letters[i:i+len(py)], py) {
+				continue
+			}
+
+			// 与直接匹配一致，拼音前后都必须是单词边界，避免匹配到 toppingguo 这样的其他单词
+			if !starts[i] || (i+len(py) < len(letters) && !starts[i+len(py)]) {
+				continue
+			}
+
+			spans = append(spans, span{msg.pos[index[i]], msg.pos[index[i+len(py)-1]]})
+		}
+	}
+
+	return spans
+}
+
+// pinyinVariants 返回词语所有可能的无声调拼音，词语不含汉字或包含无法转换的字符时返回空
+func pinyinVariants(word []rune) []string {
+	args := pinyin.NewArgs()
+	args.Heteronym = true
+
+	variants := []string{""}
+	hasHan := false
+
+	for _, r := range word {
+		var readings []string
+
+		switch {
+		case unicode.Is(unicode.Han, r):
+			hasHan = true
+			for _, py := range pinyin.SinglePinyin(r, args) {
+				readings = append(readings, py)
+				// ü 在拼音中写作 v，玩家也可能直接写 u
+				if strings.Contains(py, "v") {
+					readings = append(readings, strings.ReplaceAll(py, "v", "u"))
+				}
+			}
+		case isLetter(r):
+			readings = []string{string(r)}
+		}
+
+		if len(readings) == 0 {
+			return nil
+		}
+
+		next := make([]string, 0, len(variants)*len(readings))
+		for _, prefix := range variants {
+			for _, py := range readings {
+				if len(next) < maxPinyinVariants {
+					next = append(next, prefix+py)
+				}
+			}
+		}
+		variants = next
+	}
+
+	if !hasHan {
+		return nil
+	}
+
+	return variants
+}
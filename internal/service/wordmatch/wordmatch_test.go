@@ -0,0 +1,102 @@
+package wordmatch
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestFind(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		word    string
+		want    bool
+	}{
+		// 应当检测到的泄词
+		{"汉字原文", "我觉得它是一种水果，苹果很好吃", "苹果", true},
+		{"繁体字", "蘋果很好吃", "苹果", true},
+		{"词语为繁体字", "苹果很好吃", "蘋果", true},
+		{"字间插入空白和标点", "苹 果，苹-果", "苹果", true},
+		{"拼音", "it is ping guo", "苹果", true},
+		{"拼音连写", "pingguo", "苹果", true},
+		{"拼音带声调", "píng guǒ", "苹果", true},
+		{"拼音大写", "PINGGUO", "苹果", true},
+		{"ü 写作 v", "lvse de", "绿色", true},
+		{"ü 写作 u", "lu se", "绿色", true},
+		{"英文单词", "I like Apple pie", "apple", true},
+		{"全角字母", "ＡＰＰＬＥ", "apple", true},
+		{"英文单词后接汉字", "apple很甜", "apple", true},
+		{"单字词汉字", "一只猫在睡觉", "猫", true},
+
+		// 不应当检测到的泄词
+		{"无关内容", "它是圆的，可以吃", "苹果", false},
+		{"单字词的拼音", "mao zedong", "猫", false},
+		{"单字词的拼音单独出现", "it says mao", "猫", false},
+		{"英文单词是其他单词的一部分", "education", "cat", false},
+		{"英文单词是其他单词的前缀", "catalog", "cat", false},
+		{"拼音是其他单词的一部分", "shopping guide", "苹果", false},
+		{"拼音在其他单词中间", "toppingguo", "苹果", false},
+		{"拼音在其他单词开头", "pingguoshu", "苹果", false},
+		{"拼音跨越其他单词", "topping guo", "苹果", false},
+		{"拼音后接其他单词", "ping guoji", "苹果", false},
+		{"只有部分汉字", "苹是一个字", "苹果", false},
+		{"空词语", "任何内容", "", false},
+		{"词语只有标点", "你好！", "！", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := slices.Contains(Find(tt.message, tt.word), tt.word)
+			if got != tt.want {
+				t.Errorf("Find(%q, %q) = %v, want %v", tt.message, tt.word, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMask(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		words   []string
+		want    string
+	}{
+		{"汉字", "苹果很好吃", []string{"苹果"}, "**很好吃"},
+		{"繁体字", "蘋果很好吃", []string{"苹果"}, "**很好吃"},
+		{"拼音", "ping guo hao chi", []string{"苹果"}, "******** hao chi"},
+		{"多个词语", "苹果和梨", []string{"苹果", "梨"}, "**和*"},
+		{"单字词的拼音不遮挡", "mao zedong", []string{"猫"}, "mao zedong"},
+		{"没有出现", "它是圆的", []string{"苹果"}, "它是圆的"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Mask(tt.message, tt.words...); got != tt.want {
+				t.Errorf("Mask(%q, %q) = %q, want %q", tt.message, tt.words, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEqual(t *testing.T) {
+	tests := []struct {
+		a    string
+		b    string
+		want bool
+	}{
+		{"Apple", "apple", true},
+		{"ａｐｐｌｅ", "apple", true},
+		{"蘋果", "苹果", true},
+		{" 苹 果 ", "苹果", true},
+		{"苹果", "pingguo", false},
+		{"苹果", "香蕉", false},
+		{"", "", false},
+		{"！", "？", false},
+	}
+
+	for _, tt := range tests {
+		if got := Equal(tt.a, tt.b); got != tt.want {
+			t.Errorf("Equal(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
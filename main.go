@@ -18,7 +18,7 @@ func main() {
 	// 组装应用状态
 	appState := state.NewAppState(
 		cfg,
-		service.NewRoomService(),
+		service.NewRoomService(cfg),
 	)
 
 	// 启动服务器